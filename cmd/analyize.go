@@ -41,11 +41,39 @@ func init() {
 func Flags() *pflag.FlagSet {
 	fs := pflag.NewFlagSet("analyze", pflag.ExitOnError)
 	fs.StringP("src", "s", "", "Path to the project")
+	fs.String("focus", "", "Only render the graph around this function")
+	fs.Int("depth", 0, "Maximum distance from the focus function (0 for unlimited)")
+	fs.String("direction", "both", "Direction to walk from the focus function: callers, callees or both")
+	fs.String("include", "", "Only render functions matching this regular expression")
+	fs.String("exclude", "", "Drop functions matching this regular expression and those only reachable through them")
+	fs.String("level", "function", "Aggregation level: function, type, file, package, directory (top-level) or module")
+	fs.StringP("format", "f", "dot", "Output format: dot, html, mermaid or json")
+	fs.Bool("complexity", false, "Colour nodes by cyclomatic complexity")
+	fs.String("cover", "", "Coverage profile from go test -coverprofile to colour nodes by")
+	fs.String("profile", "", "pprof profile to size nodes by runtime cost")
+	fs.String("sample-type", "", "Sample type of the profile, e.g. cpu or alloc_space (default from the profile)")
+	fs.Int("max-nodes", tools.DefaultMermaidNodes, "Nodes above which a Mermaid flowchart is collapsed to packages (0 for no limit)")
 	return fs
 }
 
 func Analyze(cmd *cobra.Command, args []string) error {
 
-	return tools.Analyze(viper.GetString("src"), viper.GetString("output"))
+	return tools.Analyze(graphProject(), viper.GetString("output"), tools.AnalyzeOptions{
+		Filter: tools.FilterOptions{
+			Focus:     viper.GetString("focus"),
+			Depth:     viper.GetInt("depth"),
+			Direction: viper.GetString("direction"),
+			Include:   viper.GetString("include"),
+			Exclude:   viper.GetString("exclude"),
+		},
+		Level:  viper.GetString("level"),
+		Format: viper.GetString("format"),
+
+		Complexity: viper.GetBool("complexity"),
+		Cover:      viper.GetString("cover"),
+		Profile:    viper.GetString("profile"),
+		SampleType: viper.GetString("sample-type"),
+		MaxNodes:   viper.GetInt("max-nodes"),
+	})
 
 }
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// capabilitiesCmd represents the capabilities command
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Show which functions can use the file system, network, exec and more",
+	Long: `Classifies the standard library calls of every function into capabilities
+(fs, network, exec, env, unsafe, reflect, cgo, syscall) and propagates them to
+all callers, printing the shortest call path that proves each capability.
+Third-party packages are not analysed; every one called is reported as an
+unknown capability with its own path.
+
+Method calls such as f.Write or client.Do are classified when the receiver's
+type is declared in the project: as a parameter, variable, struct field,
+composite literal or result of a project function. Values only returned by a
+call into another package, such as f, _ := os.Open(name), are not resolved,
+but that call is classified itself.`,
+	RunE:         Capabilities,
+	SilenceUsage: true,
+}
+
+func init() {
+	capabilitiesCmd.Flags().AddFlagSet(CapabilitiesFlags())
+	rootCmd.AddCommand(capabilitiesCmd)
+}
+
+func CapabilitiesFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("capabilities", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringSliceP("capability", "c", nil, "Only report these capabilities")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Capabilities(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Capabilities(src, tools.CapabilitiesOptions{
+		Capabilities: viper.GetStringSlice("capability"),
+		Format:       viper.GetString("format"),
+		Output:       viper.GetString("output"),
+	})
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Run rules against a project and fail on new violations",
+	Long: `Runs the analysis rules against a project. When a baseline file exists only
+violations that are not recorded in it fail the check, and baseline entries
+that no longer occur are reported so the baseline can shrink over time.
+
+Record the current violations with:
+
+  gpa check --write-baseline`,
+	RunE:         Check,
+	SilenceUsage: true,
+}
+
+func init() {
+	checkCmd.Flags().AddFlagSet(CheckFlags())
+	rootCmd.AddCommand(checkCmd)
+}
+
+func CheckFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("check", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.String("baseline", ".gpa-baseline.json", "Baseline file of known violations")
+	fs.Bool("write-baseline", false, "Record the current violations in the baseline file")
+	fs.StringSlice("rules", nil, "Rules to run (default all)")
+	return fs
+}
+
+func Check(cmd *cobra.Command, args []string) error {
+	project, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Check(project, tools.CheckOptions{
+		Rules:         viper.GetStringSlice("rules"),
+		BaselinePath:  viper.GetString("baseline"),
+		WriteBaseline: viper.GetBool("write-baseline"),
+	})
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// clonesCmd represents the clones command
+var clonesCmd = &cobra.Command{
+	Use:   "clones",
+	Short: "Find duplicated and near-duplicated functions",
+	Long: `Normalizes the syntax tree of every function, abstracting identifiers and
+literal values, and reports functions with identical structure as exact clones
+and functions with similar structure as near clones, with their similarity.`,
+	RunE:         Clones,
+	SilenceUsage: true,
+}
+
+func init() {
+	clonesCmd.Flags().AddFlagSet(ClonesFlags())
+	rootCmd.AddCommand(clonesCmd)
+}
+
+func ClonesFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("clones", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.Int("min-tokens", 50, "Smallest function size, in syntax tree tokens, to compare")
+	fs.Float64("threshold", 0.8, "Smallest similarity, between 0 and 1, reported as a near clone")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Clones(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Clones(src, tools.ClonesOptions{
+		MinTokens: viper.GetInt("min-tokens"),
+		Threshold: viper.GetFloat64("threshold"),
+		Format:    viper.GetString("format"),
+		Output:    viper.GetString("output"),
+	})
+}
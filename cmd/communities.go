@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// communitiesCmd represents the communities command
+var communitiesCmd = &cobra.Command{
+	Use:   "communities",
+	Short: "Propose a package layout by clustering the call graph",
+	Long: `Clusters the functions of a project with the Louvain community detection
+method and compares the clusters with the current packages. Functions with more
+calls into another package than within their own are reported as misplaced.
+The DOT output draws each proposed package as a cluster.`,
+	RunE:         Communities,
+	SilenceUsage: true,
+}
+
+func init() {
+	communitiesCmd.Flags().AddFlagSet(CommunitiesFlags())
+	rootCmd.AddCommand(communitiesCmd)
+}
+
+func CommunitiesFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("communities", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text, json or dot")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Communities(cmd *cobra.Command, args []string) error {
+	return tools.Communities(graphProject(), tools.CommunitiesOptions{
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
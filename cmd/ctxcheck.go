@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ctxcheckCmd represents the ctxcheck command
+var ctxcheckCmd = &cobra.Command{
+	Use:   "ctxcheck",
+	Short: "Report breaks in the context.Context chain",
+	Long: `Reports functions that accept a context.Context but call other functions with
+context.Background() or context.TODO() instead of passing it along, and
+functions without a context parameter that call functions requiring one.`,
+	RunE:         ContextCheck,
+	SilenceUsage: true,
+}
+
+func init() {
+	ctxcheckCmd.Flags().AddFlagSet(ContextCheckFlags())
+	rootCmd.AddCommand(ctxcheckCmd)
+}
+
+func ContextCheckFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("ctxcheck", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func ContextCheck(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.ContextCheck(src, tools.ContextCheckOptions{
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
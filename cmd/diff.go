@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <rev1> <rev2>",
+	Short: "Compare the call graph of two revisions",
+	Long: `Analyzes two revisions and reports added and removed functions, added and
+removed calls, changed signatures and new cycles. Each revision is either a
+source directory or a git revision, which is checked out into a temporary
+worktree. For example:
+
+  gpa diff main HEAD --format html --output diff.html`,
+	Args:         cobra.ExactArgs(2),
+	RunE:         Diff,
+	SilenceUsage: true,
+}
+
+func init() {
+	diffCmd.Flags().AddFlagSet(DiffFlags())
+	rootCmd.AddCommand(diffCmd)
+}
+
+func DiffFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("diff", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project inside the git repository")
+	fs.StringP("format", "f", "text", "Output format: text, json, dot or html")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Diff(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Diff(args[0], args[1], tools.DiffOptions{
+		Src:    src,
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
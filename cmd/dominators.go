@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// dominatorsCmd represents the dominators command
+var dominatorsCmd = &cobra.Command{
+	Use:   "dominators <entry>",
+	Short: "Show the dominator tree of the call graph from an entry function",
+	Long: `Computes the dominator tree of the functions reachable from an entry: a
+function dominates another when every call path from the entry to it goes
+through the first. Reports the immediate dominator of each function and the
+chokepoints whose changes affect everything beneath them. For example:
+
+  gpa dominators main --func parse`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         Dominators,
+	SilenceUsage: true,
+}
+
+func init() {
+	dominatorsCmd.Flags().AddFlagSet(DominatorsFlags())
+	rootCmd.AddCommand(dominatorsCmd)
+}
+
+func DominatorsFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("dominators", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.String("func", "", "Only show the functions every path to this function goes through")
+	fs.Int("min", 2, "Functions a chokepoint must dominate")
+	fs.StringP("format", "f", "text", "Output format: text, json or dot")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Dominators(cmd *cobra.Command, args []string) error {
+	return tools.Dominators(graphProject(), args[0], tools.DominatorsOptions{
+		Function:     viper.GetString("func"),
+		MinDominated: viper.GetInt("min"),
+		Format:       viper.GetString("format"),
+		Output:       viper.GetString("output"),
+	})
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// errcheckCmd represents the errcheck command
+var errcheckCmd = &cobra.Command{
+	Use:   "errcheck",
+	Short: "Report call sites that ignore error results",
+	Long: `Lists every call site where the error result of the callee is discarded, as
+an expression statement, a blank assignment, or a defer or go statement.
+Results are ranked by the number of callers of the callee. Functions known to
+be safe, like fmt.Println, are skipped; add more with --allow:
+
+  gpa errcheck --allow 'os.Remove*' --allow '*.Close'`,
+	RunE:         ErrCheck,
+	SilenceUsage: true,
+}
+
+func init() {
+	errcheckCmd.Flags().AddFlagSet(ErrCheckFlags())
+	rootCmd.AddCommand(errcheckCmd)
+}
+
+func ErrCheckFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("errcheck", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringSlice("allow", nil, "Glob patterns of callees whose errors may be ignored")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func ErrCheck(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.ErrCheck(src, tools.ErrCheckOptions{
+		Allow:  viper.GetStringSlice("allow"),
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
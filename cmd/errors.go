@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// errorsCmd represents the errors command
+var errorsCmd = &cobra.Command{
+	Use:   "errors",
+	Short: "Catalog the errors of a project and where they propagate",
+	Long: `Lists every sentinel error variable, custom error type and errors.New or
+fmt.Errorf call of a project, the functions that create, wrap (%w) and compare
+(errors.Is/As) them, and which sentinel errors can propagate up the call graph
+to each entrypoint. Sentinels that no function returns are flagged.`,
+	RunE:         Errors,
+	SilenceUsage: true,
+}
+
+func init() {
+	errorsCmd.Flags().AddFlagSet(ErrorsFlags())
+	rootCmd.AddCommand(errorsCmd)
+}
+
+func ErrorsFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("errors", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Errors(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Errors(src, tools.ErrorsOptions{
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
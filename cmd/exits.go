@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// exitsCmd represents the exits command
+var exitsCmd = &cobra.Command{
+	Use:   "exits",
+	Short: "List panics and process exits reachable from entrypoints",
+	Long: `Lists every call to panic, os.Exit, log.Fatal* and runtime.Goexit together
+with the shortest call path from each entrypoint that can reach it.`,
+	RunE:         Exits,
+	SilenceUsage: true,
+}
+
+func init() {
+	exitsCmd.Flags().AddFlagSet(ExitsFlags())
+	rootCmd.AddCommand(exitsCmd)
+}
+
+func ExitsFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("exits", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Exits(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Exits(src, tools.ExitsOptions{
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// goroutinesCmd represents the goroutines command
+var goroutinesCmd = &cobra.Command{
+	Use:   "goroutines",
+	Short: "Show where goroutines are started and what they call",
+	Long: `Lists every go statement of a project, the function or closure it launches,
+everything that goroutine transitively calls and the entrypoints that can
+spawn it. The DOT output draws each goroutine as its own cluster.`,
+	RunE:         Goroutines,
+	SilenceUsage: true,
+}
+
+func init() {
+	goroutinesCmd.Flags().AddFlagSet(GoroutinesFlags())
+	rootCmd.AddCommand(goroutinesCmd)
+}
+
+func GoroutinesFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("goroutines", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text, json or dot")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Goroutines(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Goroutines(src, tools.GoroutinesOptions{
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// hotspotsCmd represents the hotspots command
+var hotspotsCmd = &cobra.Command{
+	Use:   "hotspots",
+	Short: "Rank functions by churn, complexity and fan-in",
+	Long: `Reads the local git history, attributes the changed lines of every commit to
+the functions they fall in, following file renames, and ranks the functions by
+the number of commits changing them times their cyclomatic complexity, weighted
+by how many functions call them. The DOT output colours the call graph by heat.`,
+	RunE:         Hotspots,
+	SilenceUsage: true,
+}
+
+func init() {
+	hotspotsCmd.Flags().AddFlagSet(HotspotsFlags())
+	rootCmd.AddCommand(hotspotsCmd)
+}
+
+func HotspotsFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("hotspots", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.String("since", "", "Only read commits more recent than this date, e.g. \"6 months ago\"")
+	fs.Int("max-commits", 0, "Most recent commits to read (default all)")
+	fs.Int("top", 20, "Functions to list, 0 for all")
+	fs.StringP("format", "f", "text", "Output format: text, json or dot")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Hotspots(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Hotspots(src, tools.HotspotsOptions{
+		Since:      viper.GetString("since"),
+		MaxCommits: viper.GetInt("max-commits"),
+		Top:        viper.GetInt("top"),
+		Format:     viper.GetString("format"),
+		Output:     viper.GetString("output"),
+	})
+}
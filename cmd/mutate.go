@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// mutateCmd represents the mutate command
+var mutateCmd = &cobra.Command{
+	Use:   "mutate",
+	Short: "Run mutation testing on selected functions",
+	Long: `Flips conditionals, moves comparison boundaries and drops call statements in
+the functions matching a glob pattern, one mutant at a time in a temporary copy
+of the module, and runs only the tests the call graph says reach each function.
+Reports the mutants no test kills. For example:
+
+  gpa mutate --func 'parse*' --timeout 30s`,
+	RunE:         Mutate,
+	SilenceUsage: true,
+}
+
+func init() {
+	mutateCmd.Flags().AddFlagSet(MutateFlags())
+	rootCmd.AddCommand(mutateCmd)
+}
+
+func MutateFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("mutate", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.String("func", "", "Glob pattern of the functions to mutate")
+	fs.Duration("timeout", time.Minute, "Timeout of the tests run against each mutant")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Mutate(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Mutate(src, tools.MutateOptions{
+		Function: viper.GetString("func"),
+		Timeout:  viper.GetDuration("timeout"),
+		Format:   viper.GetString("format"),
+		Output:   viper.GetString("output"),
+	})
+}
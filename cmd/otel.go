@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// otelCmd represents the otel command
+var otelCmd = &cobra.Command{
+	Use:   "otel <traces.json>",
+	Short: "Map OpenTelemetry spans onto the call graph",
+	Long: `Reads an OTLP/JSON trace export, maps every span to a function using its
+code.filepath, code.lineno, code.namespace and code.function attributes or its
+name, and reports the calls between parent and child spans with their latency.
+The DOT output overlays those calls on the static call graph. For example:
+
+  gpa otel traces.json --format dot --output runtime.dot`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         Otel,
+	SilenceUsage: true,
+}
+
+func init() {
+	otelCmd.Flags().AddFlagSet(OtelFlags())
+	rootCmd.AddCommand(otelCmd)
+}
+
+func OtelFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("otel", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text, json or dot")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Otel(cmd *cobra.Command, args []string) error {
+	return tools.Otel(args[0], tools.OtelOptions{
+		Src:    graphProject(),
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
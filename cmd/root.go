@@ -4,6 +4,8 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
@@ -23,6 +25,10 @@ to quickly create a Cobra application.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Flags are defined per command, so bind the ones of the command being run.
+		return viper.BindPFlags(cmd.Flags())
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -34,7 +40,26 @@ func Execute() {
 	}
 }
 
+// graphProject returns the project of the commands that only need the call
+// graph: the graph snapshot given with --from, or the source directory.
+func graphProject() string {
+	if from := viper.GetString("from"); from != "" {
+		return from
+	}
+	return viper.GetString("src")
+}
+
+// sourceProject returns the source directory of the commands that read the
+// project sources, which cannot run from a graph snapshot.
+func sourceProject(cmd *cobra.Command) (string, error) {
+	if viper.GetString("from") != "" {
+		return "", fmt.Errorf("%s reads the project sources and cannot run from a graph snapshot, use --src instead of --from", cmd.Name())
+	}
+	return viper.GetString("src"), nil
+}
+
 func init() {
+	rootCmd.PersistentFlags().String("from", "", "Graph snapshot from analyze --format json to run from instead of parsing the project, for the commands that only need the call graph")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// sequenceCmd represents the sequence command
+var sequenceCmd = &cobra.Command{
+	Use:   "sequence <func>",
+	Short: "Render the calls made from a function as a sequence diagram",
+	Long: `Walks the body of a function in source order and renders its calls as a
+sequence diagram with a participant per package or type, expanding the calls
+to other project functions up to the given depth. Calls made by go statements
+are asynchronous messages, deferred calls come last, and loops and branches
+become loop, opt and alt blocks. For example:
+
+  gpa sequence LoadProject --depth 2 --format plantuml`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         Sequence,
+	SilenceUsage: true,
+}
+
+func init() {
+	sequenceCmd.Flags().AddFlagSet(SequenceFlags())
+	rootCmd.AddCommand(sequenceCmd)
+}
+
+func SequenceFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("sequence", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.Int("depth", 1, "Call levels to expand (1 for the calls of the function only)")
+	fs.StringP("format", "f", "mermaid", "Output format: mermaid, plantuml or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Sequence(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Sequence(src, args[0], tools.SequenceOptions{
+		Depth:  viper.GetInt("depth"),
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// servicesCmd represents the services command
+var servicesCmd = &cobra.Command{
+	Use:   "services",
+	Short: "Map the external services a project talks to",
+	Long: `Finds HTTP requests, sql.Open, gRPC Dial and Redis and Kafka client
+constructors, takes their endpoints from literal arguments and shows which
+binaries and entrypoints reach each service.`,
+	RunE:         Services,
+	SilenceUsage: true,
+}
+
+func init() {
+	servicesCmd.Flags().AddFlagSet(ServicesFlags())
+	rootCmd.AddCommand(servicesCmd)
+}
+
+func ServicesFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("services", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text, json or dot")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Services(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Services(src, tools.ServicesOptions{
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
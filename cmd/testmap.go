@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// testmapCmd represents the testmap command
+var testmapCmd = &cobra.Command{
+	Use:   "testmap",
+	Short: "Map tests to the functions they statically reach",
+	Long: `Walks the call graph from every test, benchmark, example and fuzz test,
+following the helpers declared in the _test.go files of its package, and
+reports for each function the tests reaching it and at what depth, for each
+test the functions it exercises, and the functions no test reaches. For example:
+
+  gpa testmap --name LoadProject --depth 3`,
+	RunE:         TestMap,
+	SilenceUsage: true,
+}
+
+func init() {
+	testmapCmd.Flags().AddFlagSet(TestMapFlags())
+	rootCmd.AddCommand(testmapCmd)
+}
+
+func TestMapFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("testmap", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.String("name", "", "Only show this function or test")
+	fs.Int("depth", 0, "Maximum number of calls from a test (0 for no limit)")
+	fs.StringP("format", "f", "text", "Output format: text or json")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func TestMap(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.TestMapReport(src, tools.TestMapOptions{
+		Name:   viper.GetString("name"),
+		Depth:  viper.GetInt("depth"),
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/gpa/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// traceCmd represents the trace command
+var traceCmd = &cobra.Command{
+	Use:   "trace -- <command> [args...]",
+	Short: "Record the calls a command makes at runtime",
+	Long: `Copies the module to a temporary directory, instruments every function of the
+project to record its callers, runs the command in the copy and merges the
+observed calls into the call graph. Calls the static analysis missed, such as
+calls through reflection, are reported and drawn dashed. For example:
+
+  gpa trace --format dot --output trace.dot -- go test ./...`,
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         Trace,
+	SilenceUsage: true,
+}
+
+func init() {
+	traceCmd.Flags().AddFlagSet(TraceFlags())
+	rootCmd.AddCommand(traceCmd)
+}
+
+func TraceFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("trace", pflag.ExitOnError)
+	fs.StringP("src", "s", "", "Path to the project")
+	fs.StringP("format", "f", "text", "Output format: text, json or dot")
+	fs.StringP("output", "o", "", "Output file (default stdout)")
+	return fs
+}
+
+func Trace(cmd *cobra.Command, args []string) error {
+	src, err := sourceProject(cmd)
+	if err != nil {
+		return err
+	}
+	return tools.Trace(src, args, tools.TraceOptions{
+		Format: viper.GetString("format"),
+		Output: viper.GetString("output"),
+	})
+}
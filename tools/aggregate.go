@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Aggregation levels supported by AggregateGraph, from finest to coarsest.
+const (
+	LevelFunction  = "function"
+	LevelType      = "type"
+	LevelFile      = "file"
+	LevelPackage   = "package"
+	LevelDirectory = "directory"
+	LevelModule    = "module"
+)
+
+// AggregateGraph collapses the functions of the graph into one node per type,
+// file, package, top-level directory or module. Aggregate nodes list their members and
+// every edge records how many function-level calls it stands for.
+func AggregateGraph(graph *CallGraph, level, projectRoot string) (*CallGraph, error) {
+	groups, err := AggregateKeys(graph, level, projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		return graph, nil
+	}
+
+	aggregated := &CallGraph{Nodes: make(map[string]*FunctionNode)}
+	for _, name := range sortedKeys(graph.Nodes) {
+		key := groups[name]
+		node, ok := aggregated.Nodes[key]
+		if !ok {
+			node = &FunctionNode{
+				Name:       key,
+				Calls:      make(map[string]*FunctionNode),
+				CalledBy:   make(map[string]*FunctionNode),
+				CallCounts: make(map[string]int),
+				Elided:     graph.Nodes[name].Elided,
+			}
+			aggregated.Nodes[key] = node
+		}
+		node.Members = append(node.Members, name)
+	}
+	for name, orig := range graph.Nodes {
+		from := aggregated.Nodes[groups[name]]
+		for callee := range orig.Calls {
+			to := aggregated.Nodes[groups[callee]]
+			if from == to {
+				continue
+			}
+			from.Calls[to.Name] = to
+			to.CalledBy[from.Name] = from
+			from.CallCounts[to.Name]++
+		}
+	}
+	return aggregated, nil
+}
+
+// AggregateKeys maps every node of the graph to the name of the aggregate it
+// belongs to at the given level. It returns nil for the function level.
+func AggregateKeys(graph *CallGraph, level, projectRoot string) (map[string]string, error) {
+	var keyOf func(fi *FunctionInfo) string
+	switch level {
+	case "", LevelFunction:
+		return nil, nil
+	case LevelType:
+		keyOf = func(fi *FunctionInfo) string {
+			if fi.StructName == "" {
+				return packageKey(fi) + "." + fi.Name
+			}
+			return packageKey(fi) + "." + strings.TrimPrefix(fi.StructName, "*")
+		}
+	case LevelFile:
+		keyOf = func(fi *FunctionInfo) string { return filepath.ToSlash(fi.RelativeFilePath) }
+	case LevelPackage:
+		keyOf = packageKey
+	case LevelDirectory:
+		// Group by the first path segment, so cmd/a and cmd/b both fall
+		// under cmd/ and files at the project root under ./.
+		keyOf = func(fi *FunctionInfo) string {
+			dir := filepath.ToSlash(filepath.Dir(fi.RelativeFilePath))
+			return strings.SplitN(dir, "/", 2)[0] + "/"
+		}
+	case LevelModule:
+		modules := make(map[string]string)
+		keyOf = func(fi *FunctionInfo) string {
+			dir := filepath.Dir(filepath.Join(projectRoot, fi.RelativeFilePath))
+			if _, ok := modules[dir]; !ok {
+				modules[dir] = findModulePath(dir, projectRoot)
+			}
+			return modules[dir]
+		}
+	default:
+		return nil, fmt.Errorf("unknown aggregation level: %s", level)
+	}
+
+	groups := make(map[string]string)
+	for name, node := range graph.Nodes {
+		switch {
+		case node.Elided > 0:
+			groups[name] = name
+		case node.Info != nil:
+			groups[name] = keyOf(node.Info)
+		default:
+			groups[name] = externalKey(name)
+		}
+	}
+	return groups, nil
+}
+
+// packageKey identifies a package by its directory, adding the package name
+// when it differs from the directory name.
+func packageKey(fi *FunctionInfo) string {
+	dir := filepath.ToSlash(filepath.Dir(fi.RelativeFilePath))
+	if filepath.Base(dir) == fi.PkgName {
+		return dir
+	}
+	return dir + " (" + fi.PkgName + ")"
+}
+
+// externalKey groups a function outside the project by the qualifier of its name.
+func externalKey(name string) string {
+	if i := strings.LastIndex(name, "."); i > 0 {
+		return "external:" + name[:i]
+	}
+	return "external:builtin"
+}
+
+// findModulePath returns the module path declared by the nearest go.mod at or
+// above dir, without leaving projectRoot.
+func findModulePath(dir, projectRoot string) string {
+	root, _ := filepath.Abs(projectRoot)
+	for {
+		if path := readModulePath(filepath.Join(dir, "go.mod")); path != "" {
+			return path
+		}
+		abs, _ := filepath.Abs(dir)
+		parent := filepath.Dir(dir)
+		if abs == root || parent == dir {
+			return "(no module)"
+		}
+		dir = parent
+	}
+}
+
+// readModulePath returns the module path of a go.mod file or "" if it cannot be read.
+func readModulePath(goMod string) string {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAggregateKeys tests the AggregateKeys function.
+func TestAggregateKeys(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"main.go": `package main
+
+func run() {
+	serve()
+	migrate()
+}
+`,
+		"cmd/a/main.go": `package main
+
+func serve() {}
+`,
+		"cmd/b/main.go": `package main
+
+func migrate() {}
+`,
+	})
+
+	tests := []struct {
+		level string
+		want  map[string]string
+	}{
+		{
+			level: LevelPackage,
+			want:  map[string]string{"run": ". (main)", "serve": "cmd/a (main)", "migrate": "cmd/b (main)"},
+		},
+		{
+			level: LevelDirectory,
+			want:  map[string]string{"run": "./", "serve": "cmd/", "migrate": "cmd/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			got, err := AggregateKeys(p.Graph, tt.level, p.Root)
+			if err != nil {
+				t.Fatalf("AggregateKeys() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AggregateKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
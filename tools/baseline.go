@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BaselineVersion is the current version of the baseline file format.
+const BaselineVersion = 1
+
+// Baseline records known violations so that only new ones fail a check.
+type Baseline struct {
+	Version    int             `json:"version"`
+	Violations []BaselineEntry `json:"violations"`
+}
+
+// BaselineEntry is a recorded violation. Entries are matched by fingerprint,
+// which ignores line numbers so that unrelated edits do not invalidate them.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	Function    string `json:"function"`
+	File        string `json:"file"`
+	Message     string `json:"message"`
+	Count       int    `json:"count"`
+}
+
+// Fingerprint returns a stable identifier for a violation built from the rule,
+// the package directory, the function and the message.
+func (v Violation) Fingerprint() string {
+	h := sha256.New()
+	for _, part := range []string{v.Rule, filepath.ToSlash(filepath.Dir(v.File)), v.Function, v.Message} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// NewBaseline builds a baseline from the given violations.
+func NewBaseline(violations []Violation) *Baseline {
+	entries := make(map[string]*BaselineEntry)
+	for _, v := range violations {
+		fp := v.Fingerprint()
+		if e, ok := entries[fp]; ok {
+			e.Count++
+			continue
+		}
+		entries[fp] = &BaselineEntry{
+			Fingerprint: fp,
+			Rule:        v.Rule,
+			Function:    v.Function,
+			File:        v.File,
+			Message:     v.Message,
+			Count:       1,
+		}
+	}
+
+	b := &Baseline{Version: BaselineVersion}
+	for _, e := range entries {
+		b.Violations = append(b.Violations, *e)
+	}
+	sort.Slice(b.Violations, func(i, j int) bool {
+		if b.Violations[i].File != b.Violations[j].File {
+			return b.Violations[i].File < b.Violations[j].File
+		}
+		return b.Violations[i].Fingerprint < b.Violations[j].Fingerprint
+	})
+	return b
+}
+
+// Compare splits violations into those not covered by the baseline and returns
+// the baseline entries that no longer occur. When a fingerprint occurs more
+// often than recorded, the extra occurrences are reported as new; when it
+// occurs less often, its entry is reported as fixed with Count set to the
+// number of occurrences that went away.
+func (b *Baseline) Compare(violations []Violation) (newViolations []Violation, fixed []BaselineEntry) {
+	remaining := make(map[string]int)
+	for _, e := range b.Violations {
+		remaining[e.Fingerprint] += e.Count
+	}
+
+	for _, v := range violations {
+		fp := v.Fingerprint()
+		if remaining[fp] > 0 {
+			remaining[fp]--
+			continue
+		}
+		newViolations = append(newViolations, v)
+	}
+
+	for _, e := range b.Violations {
+		if n := remaining[e.Fingerprint]; n > 0 {
+			e.Count = n
+			fixed = append(fixed, e)
+			delete(remaining, e.Fingerprint)
+		}
+	}
+	return newViolations, fixed
+}
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if b.Version != BaselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+	return &b, nil
+}
+
+// WriteBaseline writes a baseline file.
+func WriteBaseline(path string, b *Baseline) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestBaselineCompare tests that only violations missing from the baseline are reported as new.
+func TestBaselineCompare(t *testing.T) {
+	known := Violation{Rule: "unused-function", Function: "helper", File: "pkg/a.go", Line: 10, Message: "function helper has no callers"}
+	other := Violation{Rule: "unused-function", Function: "other", File: "pkg/a.go", Line: 20, Message: "function other has no callers"}
+
+	tests := []struct {
+		name      string
+		baseline  []Violation
+		current   []Violation
+		wantNew   []Violation
+		wantFixed map[string]int
+	}{
+		{
+			name:     "Known violation moved to another line",
+			baseline: []Violation{known},
+			current:  []Violation{{Rule: known.Rule, Function: known.Function, File: known.File, Line: 42, Message: known.Message}},
+		},
+		{
+			name:     "Known violation moved to another file in the package",
+			baseline: []Violation{known},
+			current:  []Violation{{Rule: known.Rule, Function: known.Function, File: "pkg/b.go", Line: 10, Message: known.Message}},
+		},
+		{
+			name:     "New violation",
+			baseline: []Violation{known},
+			current:  []Violation{known, other},
+			wantNew:  []Violation{other},
+		},
+		{
+			name:     "Additional occurrence of a known fingerprint",
+			baseline: []Violation{known},
+			current:  []Violation{known, known},
+			wantNew:  []Violation{known},
+		},
+		{
+			name:      "Fixed violation",
+			baseline:  []Violation{known, other},
+			current:   []Violation{other},
+			wantFixed: map[string]int{"helper": 1},
+		},
+		{
+			name:      "Fewer occurrences of a known fingerprint",
+			baseline:  []Violation{known, known, known, other},
+			current:   []Violation{known, other},
+			wantFixed: map[string]int{"helper": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotNew, gotFixed := NewBaseline(tt.baseline).Compare(tt.current)
+			if !reflect.DeepEqual(gotNew, tt.wantNew) {
+				t.Errorf("Compare() new = %v, want %v", gotNew, tt.wantNew)
+			}
+			fixed := make(map[string]int)
+			for _, e := range gotFixed {
+				fixed[e.Function] += e.Count
+			}
+			if tt.wantFixed == nil {
+				tt.wantFixed = map[string]int{}
+			}
+			if !reflect.DeepEqual(fixed, tt.wantFixed) {
+				t.Errorf("Compare() fixed occurrences = %v, want %v", fixed, tt.wantFixed)
+			}
+		})
+	}
+}
+
+// TestBaselineRoundTrip tests that a written baseline loads back unchanged.
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	want := NewBaseline([]Violation{{Rule: "unused-function", Function: "helper", File: "a.go", Message: "m"}})
+	if err := WriteBaseline(path, want); err != nil {
+		t.Fatalf("WriteBaseline() error = %v", err)
+	}
+	got, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadBaseline() = %v, want %v", got, want)
+	}
+}
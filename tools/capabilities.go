@@ -0,0 +1,598 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// Capabilities a function can use through the standard library.
+const (
+	CapabilityFS      = "fs"
+	CapabilityNetwork = "network"
+	CapabilityExec    = "exec"
+	CapabilityEnv     = "env"
+	CapabilityUnsafe  = "unsafe"
+	CapabilityReflect = "reflect"
+	CapabilityCgo     = "cgo"
+	CapabilitySyscall = "syscall"
+	// CapabilityUnknown marks calls into third-party packages, whose source is
+	// not analysed and may use any capability.
+	CapabilityUnknown = "unknown"
+)
+
+// osCapabilities classifies the os functions that do not touch the file system.
+var osCapabilities = map[string]string{
+	"Getenv":          CapabilityEnv,
+	"LookupEnv":       CapabilityEnv,
+	"Setenv":          CapabilityEnv,
+	"Unsetenv":        CapabilityEnv,
+	"Clearenv":        CapabilityEnv,
+	"Environ":         CapabilityEnv,
+	"ExpandEnv":       CapabilityEnv,
+	"StartProcess":    CapabilityExec,
+	"FindProcess":     CapabilityExec,
+	"Exit":            "",
+	"Getpid":          "",
+	"Getppid":         "",
+	"Getuid":          "",
+	"Getgid":          "",
+	"Hostname":        "",
+	"IsExist":         "",
+	"IsNotExist":      "",
+	"IsPermission":    "",
+	"IsTimeout":       "",
+	"NewSyscallError": "",
+}
+
+// syscallCapabilities classifies the syscall functions that have a more specific capability.
+var syscallCapabilities = map[string]string{
+	"Exec":         CapabilityExec,
+	"ForkExec":     CapabilityExec,
+	"StartProcess": CapabilityExec,
+	"Getenv":       CapabilityEnv,
+	"Setenv":       CapabilityEnv,
+	"Unsetenv":     CapabilityEnv,
+	"Clearenv":     CapabilityEnv,
+	"Environ":      CapabilityEnv,
+}
+
+// nonNetworkPackages are packages under net/ that only parse or format data.
+var nonNetworkPackages = map[string]bool{
+	"net/url":            true,
+	"net/netip":          true,
+	"net/mail":           true,
+	"net/textproto":      true,
+	"net/http/cookiejar": true,
+}
+
+// classifyCall returns the capability a call to function name of the package
+// with the given import path uses directly, or "" for none.
+func classifyCall(importPath, name string) string {
+	switch importPath {
+	case "os":
+		if c, ok := osCapabilities[name]; ok {
+			return c
+		}
+		return CapabilityFS
+	case "io/ioutil", "io/fs":
+		return CapabilityFS
+	case "path/filepath":
+		switch name {
+		case "Walk", "WalkDir", "Glob", "EvalSymlinks", "Abs":
+			return CapabilityFS
+		}
+		return ""
+	case "os/exec", "plugin":
+		return CapabilityExec
+	case "syscall", "golang.org/x/sys/unix", "golang.org/x/sys/windows":
+		if c, ok := syscallCapabilities[name]; ok {
+			return c
+		}
+		return CapabilitySyscall
+	case "unsafe":
+		return CapabilityUnsafe
+	case "reflect":
+		return CapabilityReflect
+	case "C":
+		return CapabilityCgo
+	case "crypto/tls":
+		if strings.HasPrefix(name, "Dial") || name == "Listen" {
+			return CapabilityNetwork
+		}
+		return ""
+	case "net":
+		return CapabilityNetwork
+	}
+	if strings.HasPrefix(importPath, "net/") && !nonNetworkPackages[importPath] {
+		return CapabilityNetwork
+	}
+	return ""
+}
+
+// classifyMethod returns the capability a call to method name on a value of
+// the type typeName of the package with the given import path uses directly,
+// or "" for none.
+func classifyMethod(importPath, typeName, name string) string {
+	switch importPath {
+	case "os":
+		switch typeName {
+		case "File", "Root":
+			return CapabilityFS
+		case "Process":
+			return CapabilityExec
+		}
+	case "io/fs":
+		return CapabilityFS
+	case "os/exec":
+		return CapabilityExec
+	case "reflect":
+		return CapabilityReflect
+	case "database/sql":
+		// Queries reach the database through its driver, usually over the network.
+		switch typeName {
+		case "DB", "Conn", "Tx", "Stmt":
+			return CapabilityNetwork
+		}
+	case "net":
+		switch typeName {
+		case "Conn", "PacketConn", "Listener", "Dialer", "ListenConfig", "Resolver",
+			"TCPConn", "TCPListener", "UDPConn", "UnixConn", "UnixListener", "IPConn":
+			return CapabilityNetwork
+		}
+	case "net/http":
+		switch typeName {
+		case "Client", "Transport", "RoundTripper", "Server", "ResponseWriter":
+			return CapabilityNetwork
+		}
+	case "crypto/tls":
+		switch typeName {
+		case "Conn", "Dialer":
+			return CapabilityNetwork
+		}
+	}
+	return ""
+}
+
+// packageVarTypes are the types of the standard library variables whose
+// methods use a capability.
+var packageVarTypes = map[string]typeRef{
+	"net/http.DefaultClient":    {importPath: "net/http", name: "Client"},
+	"net/http.DefaultTransport": {importPath: "net/http", name: "RoundTripper"},
+}
+
+// typeRef is a named type: its import path, empty for the types of the
+// project, and its name.
+type typeRef struct {
+	importPath string
+	name       string
+}
+
+// typeExpr is a type expression and the file declaring it, whose imports
+// resolve it.
+type typeExpr struct {
+	expr ast.Expr
+	sf   *sourceFile
+}
+
+// receiverTypes resolves the types of the values methods are called on from
+// their declarations, without type checking: parameters, variables, composite
+// literals, struct fields of project types and results of project functions.
+type receiverTypes struct {
+	fields  map[string]map[string]typeExpr // Package directory -> "Type.field" -> field type.
+	results map[string]map[string]typeExpr // Package directory -> function -> first result type.
+}
+
+// newReceiverTypes indexes the struct fields and function results of the files.
+func newReceiverTypes(files []*sourceFile) *receiverTypes {
+	rt := &receiverTypes{fields: make(map[string]map[string]typeExpr), results: make(map[string]map[string]typeExpr)}
+	for _, sf := range files {
+		dir := filepath.Dir(sf.RelPath)
+		if rt.fields[dir] == nil {
+			rt.fields[dir] = make(map[string]typeExpr)
+			rt.results[dir] = make(map[string]typeExpr)
+		}
+		for _, decl := range sf.File.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					for _, field := range st.Fields.List {
+						for _, name := range field.Names {
+							rt.fields[dir][ts.Name.Name+"."+name.Name] = typeExpr{field.Type, sf}
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Type.Results != nil && len(d.Type.Results.List) > 0 {
+					rt.results[dir][d.Name.Name] = typeExpr{d.Type.Results.List[0].Type, sf}
+				}
+			}
+		}
+	}
+	return rt
+}
+
+// typeOf resolves a type expression in the given file.
+func typeOf(sf *sourceFile, expr ast.Expr) (typeRef, bool) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return typeOf(sf, e.X)
+	case *ast.ParenExpr:
+		return typeOf(sf, e.X)
+	case *ast.Ident:
+		return typeRef{name: e.Name}, true
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			if importPath, ok := sf.Imports[x.Name]; ok {
+				return typeRef{importPath: importPath, name: e.Sel.Name}, true
+			}
+		}
+	}
+	return typeRef{}, false
+}
+
+// functionScope holds the declared types of the variables of one function.
+type functionScope struct {
+	rt   *receiverTypes
+	sf   *sourceFile
+	dir  string
+	vars map[string]typeRef
+}
+
+// scope collects the types of the receiver, parameters, results and local
+// variables of a function. Shadowing is ignored, the last declaration wins.
+func (rt *receiverTypes) scope(sf *sourceFile, fd *ast.FuncDecl) *functionScope {
+	s := &functionScope{rt: rt, sf: sf, dir: filepath.Dir(sf.RelPath), vars: make(map[string]typeRef)}
+	declare := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			if t, ok := typeOf(sf, field.Type); ok {
+				for _, name := range field.Names {
+					s.vars[name.Name] = t
+				}
+			}
+		}
+	}
+	declare(fd.Recv)
+	declare(fd.Type.Params)
+	declare(fd.Type.Results)
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if n.Type != nil {
+					if t, ok := typeOf(sf, n.Type); ok {
+						s.vars[name.Name] = t
+					}
+				} else if i < len(n.Values) {
+					if t, ok := s.valueType(n.Values[i]); ok {
+						s.vars[name.Name] = t
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				var rhs ast.Expr
+				switch {
+				case len(n.Rhs) == len(n.Lhs):
+					rhs = n.Rhs[i]
+				case i == 0 && len(n.Rhs) == 1:
+					rhs = n.Rhs[0]
+				default:
+					continue
+				}
+				if t, ok := s.valueType(rhs); ok {
+					s.vars[ident.Name] = t
+				}
+			}
+		}
+		return true
+	})
+	return s
+}
+
+// valueType returns the type of the value of an expression.
+func (s *functionScope) valueType(expr ast.Expr) (typeRef, bool) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return s.valueType(e.X)
+	case *ast.StarExpr:
+		return s.valueType(e.X)
+	case *ast.UnaryExpr:
+		return s.valueType(e.X)
+	case *ast.CompositeLit:
+		return typeOf(s.sf, e.Type)
+	case *ast.CallExpr:
+		if fun, ok := e.Fun.(*ast.Ident); ok {
+			if fun.Name == "new" && len(e.Args) == 1 {
+				return typeOf(s.sf, e.Args[0])
+			}
+			if result, ok := s.rt.results[s.dir][fun.Name]; ok {
+				return typeOf(result.sf, result.expr)
+			}
+		}
+	case *ast.Ident:
+		t, ok := s.vars[e.Name]
+		return t, ok
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			if importPath, ok := s.sf.Imports[x.Name]; ok {
+				t, ok := packageVarTypes[importPath+"."+e.Sel.Name]
+				return t, ok
+			}
+		}
+		owner, ok := s.valueType(e.X)
+		if !ok || owner.importPath != "" {
+			return typeRef{}, false
+		}
+		if field, ok := s.rt.fields[s.dir][owner.name+"."+e.Sel.Name]; ok {
+			return typeOf(field.sf, field.expr)
+		}
+	}
+	return typeRef{}, false
+}
+
+// isThirdParty reports whether an import path is outside the standard library
+// and the module with the given path.
+func isThirdParty(importPath, modulePath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	if !strings.Contains(first, ".") {
+		return false
+	}
+	return modulePath == "" || (importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/"))
+}
+
+// CapabilityUse is a capability of a function and the shortest call path that
+// proves it, ending in the standard library call that uses it, or in the
+// third-party call for unknown capabilities.
+type CapabilityUse struct {
+	Capability string   `json:"capability"`
+	Import     string   `json:"import,omitempty"` // Third-party package of an unknown capability.
+	Witness    []string `json:"witness"`
+	File       string   `json:"file"` // Location of the standard library or third-party call.
+	Line       int      `json:"line"`
+}
+
+// FunctionCapabilities lists the capabilities a function uses, directly or through its callees.
+type FunctionCapabilities struct {
+	Function     string          `json:"function"`
+	Package      string          `json:"package"`
+	File         string          `json:"file"`
+	Capabilities []CapabilityUse `json:"capabilities"`
+}
+
+// capabilityStep is the next hop from a function towards a use of a capability.
+type capabilityStep struct {
+	next string // Callee on the shortest path, or the standard library or third-party call for direct uses.
+	file string
+	line int
+	leaf bool
+}
+
+// FindCapabilities classifies the standard library calls of every function and
+// propagates the capabilities to the callers through CalledBy, keeping the
+// shortest witness path for each capability. Method calls are classified by
+// the receiver type receiverTypes resolves, so a method called on a value
+// whose type is only known from a call into another package is missed. Calls into third-party packages
+// are not followed, their source is not part of the project; each package
+// called is reported as an unknown capability with its own witness instead.
+func FindCapabilities(p *Project) ([]FunctionCapabilities, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return nil, err
+	}
+	modulePath := readModulePath(filepath.Join(findModuleRoot(root), "go.mod"))
+
+	// steps[key][function] is the next hop towards the capability, keyed by
+	// the capability, or by the third-party import path for unknown ones.
+	steps := make(map[string]map[string]capabilityStep)
+	imports := make(map[string]string) // Step key -> third-party import path.
+	var queue []struct{ capability, function string }
+	rt := newReceiverTypes(files)
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			caller := funcDeclFullName(fd)
+			scope := rt.scope(sf, fd)
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				// A package function, or a method on a value of a type
+				// declared in another package.
+				var importPath, capability, next string
+				if x, ok := sel.X.(*ast.Ident); ok && sf.Imports[x.Name] != "" {
+					importPath = sf.Imports[x.Name]
+					capability = classifyCall(importPath, sel.Sel.Name)
+					next = x.Name + "." + sel.Sel.Name
+				} else if t, ok := scope.valueType(sel.X); ok && t.importPath != "" {
+					importPath = t.importPath
+					capability = classifyMethod(importPath, t.name, sel.Sel.Name)
+					next = path.Base(importPath) + "." + t.name + "." + sel.Sel.Name
+				} else {
+					return true
+				}
+				if capability == "" && isThirdParty(importPath, modulePath) {
+					capability = CapabilityUnknown + ":" + importPath
+					imports[capability] = importPath
+				}
+				if capability == "" {
+					return true
+				}
+				if steps[capability] == nil {
+					steps[capability] = make(map[string]capabilityStep)
+				}
+				if _, seen := steps[capability][caller]; !seen {
+					steps[capability][caller] = capabilityStep{
+						next: next,
+						file: sf.RelPath,
+						line: sf.line(sel.Pos()),
+						leaf: true,
+					}
+					queue = append(queue, struct{ capability, function string }{capability, caller})
+				}
+				return true
+			})
+		}
+	}
+
+	// Breadth-first search from the direct uses up the CalledBy edges.
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		node, ok := p.Graph.Nodes[item.function]
+		if !ok {
+			continue
+		}
+		for _, caller := range sortedKeys(node.CalledBy) {
+			if _, seen := steps[item.capability][caller]; seen {
+				continue
+			}
+			steps[item.capability][caller] = capabilityStep{next: item.function}
+			queue = append(queue, struct{ capability, function string }{item.capability, caller})
+		}
+	}
+
+	var result []FunctionCapabilities
+	for _, name := range sortedKeys(p.Graph.Nodes) {
+		node := p.Graph.Nodes[name]
+		if node.Info == nil {
+			continue
+		}
+		fc := FunctionCapabilities{Function: name, Package: node.Info.PkgName, File: node.Info.RelativeFilePath}
+		for _, key := range sortedKeys(steps) {
+			step, ok := steps[key][name]
+			if !ok {
+				continue
+			}
+			use := CapabilityUse{Capability: key, Witness: []string{name}}
+			if importPath, ok := imports[key]; ok {
+				use.Capability, use.Import = CapabilityUnknown, importPath
+			}
+			for current := name; !step.leaf; step = steps[key][current] {
+				current = step.next
+				use.Witness = append(use.Witness, current)
+			}
+			use.Witness = append(use.Witness, step.next)
+			use.File, use.Line = step.file, step.line
+			fc.Capabilities = append(fc.Capabilities, use)
+		}
+		if len(fc.Capabilities) > 0 {
+			result = append(result, fc)
+		}
+	}
+	return result, nil
+}
+
+// CapabilitiesOptions configures a Capabilities run.
+type CapabilitiesOptions struct {
+	Capabilities []string // Only report these capabilities, all when empty.
+	Format       string   // Output format: text or json.
+	Output       string   // Output file, stdout when empty.
+}
+
+// Capabilities reports the capabilities each function of the project uses.
+func Capabilities(project string, opts CapabilitiesOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	result, err := FindCapabilities(p)
+	if err != nil {
+		return err
+	}
+	if len(opts.Capabilities) > 0 {
+		result = filterCapabilities(result, opts.Capabilities)
+	}
+	switch opts.Format {
+	case "", "text":
+		var buf strings.Builder
+		packages := make(map[string]map[string]int)
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "FUNCTION\tCAPABILITY\tWITNESS\tLOCATION")
+		for _, fc := range result {
+			counted := make(map[string]bool)
+			for _, use := range fc.Capabilities {
+				capability := use.Capability
+				if use.Import != "" {
+					capability += " (" + use.Import + ")"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s:%d\n", fc.Function, capability, strings.Join(use.Witness, " -> "), use.File, use.Line)
+				if packages[fc.Package] == nil {
+					packages[fc.Package] = make(map[string]int)
+				}
+				if !counted[use.Capability] {
+					counted[use.Capability] = true
+					packages[fc.Package][use.Capability]++
+				}
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		buf.WriteString("Packages:\n")
+		for _, pkg := range sortedKeys(packages) {
+			var caps []string
+			for _, capability := range sortedKeys(packages[pkg]) {
+				caps = append(caps, fmt.Sprintf("%s (%d functions)", capability, packages[pkg][capability]))
+			}
+			fmt.Fprintf(&buf, "  %s: %s\n", pkg, strings.Join(caps, ", "))
+		}
+		return writeOutput(opts.Output, []byte(buf.String()))
+	case "json":
+		return writeJSON(opts.Output, result)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
+
+// filterCapabilities keeps only the given capabilities and drops functions left without any.
+func filterCapabilities(result []FunctionCapabilities, capabilities []string) []FunctionCapabilities {
+	wanted := make(map[string]bool, len(capabilities))
+	for _, c := range capabilities {
+		wanted[c] = true
+	}
+	var filtered []FunctionCapabilities
+	for _, fc := range result {
+		var uses []CapabilityUse
+		for _, use := range fc.Capabilities {
+			if wanted[use.Capability] {
+				uses = append(uses, use)
+			}
+		}
+		if len(uses) > 0 {
+			fc.Capabilities = uses
+			filtered = append(filtered, fc)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindCapabilities tests the FindCapabilities function.
+func TestFindCapabilities(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"go.mod": "module example.com/sample\n\ngo 1.23\n",
+		"main.go": `package main
+
+import (
+	"os"
+
+	"example.com/sample/store"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	run()
+	store.Save()
+}
+
+func run() {
+	data, _ := os.ReadFile("config.yaml")
+	parse(data)
+	println(uuid.NewString())
+}
+
+func parse(data []byte) {
+	var v any
+	viper.Unmarshal(&v)
+}
+`,
+		"methods.go": `package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func (s *Store) Count() {
+	s.db.QueryRow("SELECT COUNT(*) FROM users")
+}
+
+func fetch(u string) {
+	client := &http.Client{}
+	client.Get(u)
+}
+
+func save(f *os.File) {
+	f.Write(nil)
+}
+
+func newClient() *http.Client {
+	return http.DefaultClient
+}
+
+func ping() {
+	c := newClient()
+	c.Head("https://example.com")
+}
+
+func query(u *url.URL) string {
+	return u.Query().Get("q")
+}
+`,
+	})
+	result, err := FindCapabilities(p)
+	if err != nil {
+		t.Fatalf("FindCapabilities() error = %v", err)
+	}
+	functions := make(map[string][]CapabilityUse)
+	for _, fc := range result {
+		functions[fc.Function] = fc.Capabilities
+	}
+
+	tests := []struct {
+		function string
+		want     []CapabilityUse
+	}{
+		{
+			function: "main",
+			want: []CapabilityUse{
+				{Capability: CapabilityFS, Witness: []string{"main", "run", "os.ReadFile"}, File: "main.go", Line: 17},
+				{Capability: CapabilityUnknown, Import: "github.com/google/uuid", Witness: []string{"main", "run", "uuid.NewString"}, File: "main.go", Line: 19},
+				{Capability: CapabilityUnknown, Import: "github.com/spf13/viper", Witness: []string{"main", "run", "parse", "viper.Unmarshal"}, File: "main.go", Line: 24},
+			},
+		},
+		{
+			function: "*Store.Count",
+			want: []CapabilityUse{
+				{Capability: CapabilityNetwork, Witness: []string{"*Store.Count", "sql.DB.QueryRow"}, File: "methods.go", Line: 15},
+			},
+		},
+		{
+			function: "fetch",
+			want: []CapabilityUse{
+				{Capability: CapabilityNetwork, Witness: []string{"fetch", "http.Client.Get"}, File: "methods.go", Line: 20},
+			},
+		},
+		{
+			function: "save",
+			want: []CapabilityUse{
+				{Capability: CapabilityFS, Witness: []string{"save", "os.File.Write"}, File: "methods.go", Line: 24},
+			},
+		},
+		{
+			function: "ping",
+			want: []CapabilityUse{
+				{Capability: CapabilityNetwork, Witness: []string{"ping", "http.Client.Head"}, File: "methods.go", Line: 33},
+			},
+		},
+		{function: "newClient"},
+		{function: "query"},
+		{
+			function: "parse",
+			want: []CapabilityUse{
+				{Capability: CapabilityUnknown, Import: "github.com/spf13/viper", Witness: []string{"parse", "viper.Unmarshal"}, File: "main.go", Line: 24},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			if got := functions[tt.function]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("capabilities of %s = %+v, want %+v", tt.function, got, tt.want)
+			}
+		})
+	}
+}
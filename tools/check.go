@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Violation is a single finding reported by a Rule.
+type Violation struct {
+	Rule     string `json:"rule"`
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+// Rule inspects a loaded project and reports violations.
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(p *Project) ([]Violation, error)
+}
+
+// Rules lists every rule known to `gpa check`.
+var Rules = []Rule{
+	{
+		Name:        "unused-function",
+		Description: "unexported functions that are never called within the project",
+		Check:       checkUnusedFunctions,
+	},
+	{
+		Name:        "ignored-error",
+		Description: "calls that discard the error result of their callee",
+		Check:       checkIgnoredErrors,
+	},
+	{
+		Name:        "context-chain",
+		Description: "calls that replace or lack the caller's context.Context",
+		Check:       checkContextChain,
+	},
+	{
+		Name:        "library-exit",
+		Description: "non-main packages that call os.Exit or log.Fatal",
+		Check:       checkLibraryExits,
+	},
+}
+
+// CheckOptions configures a Check run.
+type CheckOptions struct {
+	Rules         []string // Rule names to run, all rules when empty.
+	BaselinePath  string   // Baseline file used to ignore known violations.
+	WriteBaseline bool     // Record the current violations instead of comparing.
+}
+
+// Check runs the selected rules against the project and compares the result with
+// the baseline. It returns an error when violations not covered by the baseline
+// are found.
+func Check(project string, opts CheckOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	violations, err := RunRules(p, opts.Rules)
+	if err != nil {
+		return err
+	}
+
+	if opts.WriteBaseline {
+		if opts.BaselinePath == "" {
+			return fmt.Errorf("no baseline path given")
+		}
+		if err := WriteBaseline(opts.BaselinePath, NewBaseline(violations)); err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %d violations in %s\n", len(violations), opts.BaselinePath)
+		return nil
+	}
+
+	baseline := &Baseline{Version: BaselineVersion}
+	if opts.BaselinePath != "" {
+		loaded, err := LoadBaseline(opts.BaselinePath)
+		switch {
+		case err == nil:
+			baseline = loaded
+		case !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+	}
+
+	newViolations, fixed := baseline.Compare(violations)
+	for _, v := range newViolations {
+		fmt.Printf("%s:%d: [%s] %s: %s\n", v.File, v.Line, v.Rule, v.Function, v.Message)
+	}
+	if len(fixed) > 0 {
+		occurrences := 0
+		for _, e := range fixed {
+			occurrences += e.Count
+		}
+		fmt.Printf("%d baseline occurrences are gone and can be removed:\n", occurrences)
+		for _, e := range fixed {
+			fmt.Printf("  %s [%s] %s: %s (%d)\n", e.File, e.Rule, e.Function, e.Message, e.Count)
+		}
+	}
+	if len(newViolations) > 0 {
+		return fmt.Errorf("%d new violations found", len(newViolations))
+	}
+	fmt.Printf("No new violations (%d known in baseline)\n", len(violations))
+	return nil
+}
+
+// RunRules runs the named rules, or every rule when names is empty, and returns
+// the violations sorted by file, line and rule.
+func RunRules(p *Project, names []string) ([]Violation, error) {
+	selected := Rules
+	if len(names) > 0 {
+		selected = nil
+		for _, name := range names {
+			rule, ok := findRule(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown rule: %s", name)
+			}
+			selected = append(selected, rule)
+		}
+	}
+
+	var violations []Violation
+	for _, rule := range selected {
+		found, err := rule.Check(p)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		for _, v := range found {
+			v.Rule = rule.Name
+			violations = append(violations, v)
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].File != violations[j].File {
+			return violations[i].File < violations[j].File
+		}
+		if violations[i].Line != violations[j].Line {
+			return violations[i].Line < violations[j].Line
+		}
+		return violations[i].Rule < violations[j].Rule
+	})
+	return violations, nil
+}
+
+func findRule(name string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// checkUnusedFunctions reports unexported package-level functions without callers.
+func checkUnusedFunctions(p *Project) ([]Violation, error) {
+	var violations []Violation
+	for _, node := range p.Graph.Nodes {
+		fi := node.Info
+		if fi == nil || fi.StructName != "" || len(node.CalledBy) > 0 {
+			continue
+		}
+		if fi.Name == "main" || fi.Name == "init" || fi.Name == "_" || isUpperCase(fi.Name[0]) {
+			continue
+		}
+		violations = append(violations, Violation{
+			Function: node.Name,
+			File:     fi.RelativeFilePath,
+			Line:     fi.LineNumberStart,
+			Message:  fmt.Sprintf("function %s has no callers", fi.Name),
+		})
+	}
+	return violations, nil
+}
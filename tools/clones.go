@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CloneLocation is a function taking part in a clone.
+type CloneLocation struct {
+	Function  string `json:"function"`
+	File      string `json:"file"`
+	LineStart int    `json:"lineStart"`
+	LineEnd   int    `json:"lineEnd"`
+}
+
+// CloneGroup is a set of functions with the same structure once identifiers
+// are consistently renamed.
+type CloneGroup struct {
+	Hash      string          `json:"hash"`
+	Tokens    int             `json:"tokens"`
+	Functions []CloneLocation `json:"functions"`
+}
+
+// ClonePair is two functions whose structure is similar but not identical.
+type ClonePair struct {
+	A          CloneLocation `json:"a"`
+	B          CloneLocation `json:"b"`
+	Similarity float64       `json:"similarity"` // Jaccard similarity of their token shingles.
+}
+
+// CloneReport lists the exact and near clones of a project.
+type CloneReport struct {
+	Exact []CloneGroup `json:"exact"`
+	Near  []ClonePair  `json:"near"`
+}
+
+// cloneShingle is the number of consecutive tokens compared for near clones.
+const cloneShingle = 5
+
+// cloneFunction is the normalized form of a function.
+type cloneFunction struct {
+	loc      CloneLocation
+	hash     string
+	tokens   int
+	shingles map[string]bool
+}
+
+// normalizeFunction turns the signature and body of a function into two token
+// sequences: one with identifiers renamed in order of appearance, for exact
+// clones, and one with every identifier replaced by ID, for near clones.
+func normalizeFunction(fd *ast.FuncDecl) (renamed, abstract []string) {
+	names := make(map[string]string)
+	visit := func(n ast.Node) bool {
+		if n == nil {
+			renamed = append(renamed, ")")
+			abstract = append(abstract, ")")
+			return true
+		}
+		token := reflect.TypeOf(n).Elem().Name()
+		switch n := n.(type) {
+		case *ast.Ident:
+			if _, ok := names[n.Name]; !ok {
+				names[n.Name] = fmt.Sprintf("$%d", len(names))
+			}
+			renamed = append(renamed, names[n.Name])
+			abstract = append(abstract, "ID")
+			return true
+		case *ast.BasicLit:
+			token += ":" + n.Kind.String()
+		case *ast.BinaryExpr:
+			token += ":" + n.Op.String()
+		case *ast.UnaryExpr:
+			token += ":" + n.Op.String()
+		case *ast.AssignStmt:
+			token += ":" + n.Tok.String()
+		case *ast.IncDecStmt:
+			token += ":" + n.Tok.String()
+		case *ast.BranchStmt:
+			token += ":" + n.Tok.String()
+		}
+		renamed = append(renamed, token)
+		abstract = append(abstract, token)
+		return true
+	}
+	ast.Inspect(fd.Type, visit)
+	ast.Inspect(fd.Body, visit)
+	return renamed, abstract
+}
+
+// shingles returns the sets of k consecutive tokens of a sequence.
+func shingles(tokens []string, k int) map[string]bool {
+	set := make(map[string]bool)
+	for i := 0; i+k <= len(tokens); i++ {
+		set[strings.Join(tokens[i:i+k], " ")] = true
+	}
+	return set
+}
+
+// jaccard returns the Jaccard similarity of two sets.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for s := range a {
+		if b[s] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// FindClones normalizes every function of the project with at least minTokens
+// tokens, groups the functions with identical normalized structure and pairs
+// the remaining ones whose shingle similarity reaches threshold.
+func FindClones(p *Project, minTokens int, threshold float64) (*CloneReport, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var functions []cloneFunction
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			renamed, abstract := normalizeFunction(fd)
+			if len(renamed) < minTokens {
+				continue
+			}
+			sum := sha256.Sum256([]byte(strings.Join(renamed, " ")))
+			functions = append(functions, cloneFunction{
+				loc: CloneLocation{
+					Function:  funcDeclFullName(fd),
+					File:      sf.RelPath,
+					LineStart: sf.line(fd.Pos()),
+					LineEnd:   sf.line(fd.End()),
+				},
+				hash:     hex.EncodeToString(sum[:8]),
+				tokens:   len(renamed),
+				shingles: shingles(abstract, cloneShingle),
+			})
+		}
+	}
+
+	report := &CloneReport{}
+	groups := make(map[string]*CloneGroup)
+	for _, f := range functions {
+		if groups[f.hash] == nil {
+			groups[f.hash] = &CloneGroup{Hash: f.hash, Tokens: f.tokens}
+		}
+		groups[f.hash].Functions = append(groups[f.hash].Functions, f.loc)
+	}
+	for _, hash := range sortedKeys(groups) {
+		if len(groups[hash].Functions) > 1 {
+			report.Exact = append(report.Exact, *groups[hash])
+		}
+	}
+	sort.SliceStable(report.Exact, func(i, j int) bool { return report.Exact[i].Tokens > report.Exact[j].Tokens })
+
+	for i := range functions {
+		for j := i + 1; j < len(functions); j++ {
+			a, b := functions[i], functions[j]
+			if a.hash == b.hash {
+				continue
+			}
+			// The similarity cannot reach the threshold when the sizes differ too much.
+			small, large := len(a.shingles), len(b.shingles)
+			if small > large {
+				small, large = large, small
+			}
+			if float64(small) < threshold*float64(large) {
+				continue
+			}
+			if s := jaccard(a.shingles, b.shingles); s >= threshold {
+				report.Near = append(report.Near, ClonePair{A: a.loc, B: b.loc, Similarity: s})
+			}
+		}
+	}
+	sort.SliceStable(report.Near, func(i, j int) bool { return report.Near[i].Similarity > report.Near[j].Similarity })
+	return report, nil
+}
+
+// String formats the report as text.
+func (r *CloneReport) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Exact clones (%d groups):\n", len(r.Exact))
+	for _, g := range r.Exact {
+		fmt.Fprintf(&buf, "  %s (%d tokens)\n", g.Hash, g.Tokens)
+		for _, f := range g.Functions {
+			fmt.Fprintf(&buf, "    %s %s:%d-%d\n", f.Function, f.File, f.LineStart, f.LineEnd)
+		}
+	}
+	fmt.Fprintf(&buf, "Near clones (%d pairs):\n", len(r.Near))
+	for _, pair := range r.Near {
+		fmt.Fprintf(&buf, "  %.0f%% %s %s:%d-%d\n", pair.Similarity*100, pair.A.Function, pair.A.File, pair.A.LineStart, pair.A.LineEnd)
+		fmt.Fprintf(&buf, "       %s %s:%d-%d\n", pair.B.Function, pair.B.File, pair.B.LineStart, pair.B.LineEnd)
+	}
+	return buf.String()
+}
+
+// ClonesOptions configures a Clones run.
+type ClonesOptions struct {
+	MinTokens int     // Smallest function size, in normalized tokens, to compare.
+	Threshold float64 // Smallest similarity reported as a near clone.
+	Format    string  // Output format: text or json.
+	Output    string  // Output file, stdout when empty.
+}
+
+// Clones reports the duplicated functions of the project.
+func Clones(project string, opts ClonesOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	report, err := FindClones(p, opts.MinTokens, opts.Threshold)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "text":
+		return writeOutput(opts.Output, []byte(report.String()))
+	case "json":
+		return writeJSON(opts.Output, report)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
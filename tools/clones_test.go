@@ -0,0 +1,80 @@
+package tools
+
+import "testing"
+
+// TestFindClones tests the FindClones function.
+func TestFindClones(t *testing.T) {
+	src := `package sample
+
+func sumPositive(values []int) int {
+	total := 0
+	for _, v := range values {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+func addUp(items []int) int {
+	acc := 0
+	for _, item := range items {
+		if item > 0 {
+			acc += item
+		}
+	}
+	return acc
+}
+
+func addUpLogged(items []int) int {
+	acc := 0
+	for _, item := range items {
+		if item > 0 {
+			acc += item
+		}
+	}
+	println(acc)
+	return acc
+}
+
+func unrelated(name string) string {
+	switch name {
+	case "a":
+		return "first"
+	default:
+		return name + "!"
+	}
+}
+`
+	p := loadTestProject(t, map[string]string{"sample.go": src})
+
+	report, err := FindClones(p, 10, 0.6)
+	if err != nil {
+		t.Fatalf("FindClones() error = %v", err)
+	}
+
+	if len(report.Exact) != 1 || len(report.Exact[0].Functions) != 2 {
+		t.Fatalf("Exact = %+v, want one group of two functions", report.Exact)
+	}
+	if a, b := report.Exact[0].Functions[0].Function, report.Exact[0].Functions[1].Function; a != "sumPositive" || b != "addUp" {
+		t.Errorf("Exact group = %s, %s, want sumPositive, addUp", a, b)
+	}
+
+	near := make(map[string]bool)
+	for _, pair := range report.Near {
+		near[pair.A.Function+"/"+pair.B.Function] = true
+		if pair.Similarity < 0.6 || pair.Similarity >= 1 {
+			t.Errorf("Similarity of %s/%s = %v, want within [0.6, 1)", pair.A.Function, pair.B.Function, pair.Similarity)
+		}
+	}
+	for _, want := range []string{"sumPositive/addUpLogged", "addUp/addUpLogged"} {
+		if !near[want] {
+			t.Errorf("Near clones %v, missing %s", near, want)
+		}
+	}
+	for pair := range near {
+		if pair == "sumPositive/unrelated" || pair == "addUp/unrelated" {
+			t.Errorf("unexpected near clone %s", pair)
+		}
+	}
+}
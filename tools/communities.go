@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Community is a group of functions proposed as a package.
+type Community struct {
+	ID       int            `json:"id"`
+	Proposed string         `json:"proposed"` // Package most members come from.
+	Members  []string       `json:"members"`
+	Packages map[string]int `json:"packages"` // Members per current package.
+}
+
+// MisplacedFunction is a function most of whose calls cross into another package.
+type MisplacedFunction struct {
+	Function  string `json:"function"`
+	Package   string `json:"package"`
+	Suggested string `json:"suggested"`
+	Community int    `json:"community"`
+	Edges     int    `json:"edges"`     // Calls to and from project functions.
+	Home      int    `json:"home"`      // Edges within the current package.
+	Elsewhere int    `json:"elsewhere"` // Edges to the suggested package.
+}
+
+// CommunityReport is the proposed grouping of a project's functions.
+type CommunityReport struct {
+	Modularity  float64             `json:"modularity"`
+	Communities []Community         `json:"communities"`
+	Misplaced   []MisplacedFunction `json:"misplaced"`
+
+	graph     *CallGraph
+	community map[string]int
+}
+
+// louvainGraph is an undirected weighted graph; a self loop holds twice the
+// weight of the edges inside a node that stands for a community.
+type louvainGraph struct {
+	adj []map[int]float64
+}
+
+func (g *louvainGraph) degree(i int) float64 {
+	var k float64
+	for _, w := range g.adj[i] {
+		k += w
+	}
+	return k
+}
+
+// louvainPass moves every node to the neighbouring community with the largest
+// modularity gain until no move helps. It returns the community of each node
+// and whether any node moved.
+func louvainPass(g *louvainGraph, m2 float64) ([]int, bool) {
+	n := len(g.adj)
+	community := make([]int, n)
+	degrees := make([]float64, n)
+	tot := make([]float64, n)
+	for i := range community {
+		community[i] = i
+		degrees[i] = g.degree(i)
+		tot[i] = degrees[i]
+	}
+
+	moved := false
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n; i++ {
+			links := make(map[int]float64)
+			for j, w := range g.adj[i] {
+				if j != i {
+					links[community[j]] += w
+				}
+			}
+			current := community[i]
+			tot[current] -= degrees[i]
+
+			best, bestGain := current, links[current]-tot[current]*degrees[i]/m2
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			for _, c := range candidates {
+				if gain := links[c] - tot[c]*degrees[i]/m2; gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+
+			tot[best] += degrees[i]
+			if best != current {
+				community[i] = best
+				improved, moved = true, true
+			}
+		}
+	}
+	return community, moved
+}
+
+// louvain clusters the graph and returns the community of every node,
+// numbered from zero.
+func louvain(g *louvainGraph) []int {
+	membership := make([]int, len(g.adj))
+	for i := range membership {
+		membership[i] = i
+	}
+	var m2 float64
+	for i := range g.adj {
+		m2 += g.degree(i)
+	}
+	if m2 == 0 {
+		return membership
+	}
+
+	for {
+		community, moved := louvainPass(g, m2)
+		if !moved {
+			return membership
+		}
+		// Renumber the communities and fold them into single nodes.
+		index := make(map[int]int)
+		for _, c := range community {
+			if _, ok := index[c]; !ok {
+				index[c] = len(index)
+			}
+		}
+		next := &louvainGraph{adj: make([]map[int]float64, len(index))}
+		for i := range next.adj {
+			next.adj[i] = make(map[int]float64)
+		}
+		for i, row := range g.adj {
+			for j, w := range row {
+				next.adj[index[community[i]]][index[community[j]]] += w
+			}
+		}
+		for i, c := range membership {
+			membership[i] = index[community[c]]
+		}
+		g = next
+	}
+}
+
+// FindCommunities clusters the project's functions with the Louvain method,
+// treating calls as undirected edges, and compares the clusters with the
+// current packages.
+func FindCommunities(p *Project) *CommunityReport {
+	var names []string
+	for _, name := range sortedKeys(p.Graph.Nodes) {
+		if p.Graph.Nodes[name].Info != nil {
+			names = append(names, name)
+		}
+	}
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+	g := &louvainGraph{adj: make([]map[int]float64, len(names))}
+	for i := range g.adj {
+		g.adj[i] = make(map[int]float64)
+	}
+	for i, name := range names {
+		for callee := range p.Graph.Nodes[name].Calls {
+			if j, ok := index[callee]; ok && j != i {
+				g.adj[i][j]++
+				g.adj[j][i]++
+			}
+		}
+	}
+
+	membership := louvain(g)
+	report := &CommunityReport{graph: p.Graph, community: make(map[string]int)}
+
+	// Number the communities by size, largest first.
+	sizes := make(map[int]int)
+	for _, c := range membership {
+		sizes[c]++
+	}
+	var ids []int
+	for c := range sizes {
+		ids = append(ids, c)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if sizes[ids[i]] != sizes[ids[j]] {
+			return sizes[ids[i]] > sizes[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	renumber := make(map[int]int)
+	for i, c := range ids {
+		renumber[c] = i
+		report.Communities = append(report.Communities, Community{ID: i, Packages: make(map[string]int)})
+	}
+	packages := make(map[string]string, len(names))
+	for i, name := range names {
+		c := renumber[membership[i]]
+		pkg := packageKey(p.Graph.Nodes[name].Info)
+		packages[name] = pkg
+		report.community[name] = c
+		report.Communities[c].Members = append(report.Communities[c].Members, name)
+		report.Communities[c].Packages[pkg]++
+	}
+	for i := range report.Communities {
+		c := &report.Communities[i]
+		for _, pkg := range sortedKeys(c.Packages) {
+			if c.Proposed == "" || c.Packages[pkg] > c.Packages[c.Proposed] {
+				c.Proposed = pkg
+			}
+		}
+	}
+
+	// Modularity of the partition on the original graph.
+	var m2 float64
+	in := make(map[int]float64)
+	tot := make(map[int]float64)
+	for i, row := range g.adj {
+		for j, w := range row {
+			m2 += w
+			tot[membership[i]] += w
+			if membership[i] == membership[j] {
+				in[membership[i]] += w
+			}
+		}
+	}
+	if m2 > 0 {
+		for c := range tot {
+			report.Modularity += in[c]/m2 - (tot[c]/m2)*(tot[c]/m2)
+		}
+	}
+
+	// A function is misplaced when more of its edges lead to another package than stay in its own.
+	for i, name := range names {
+		edges := make(map[string]int)
+		total := 0
+		for j, w := range g.adj[i] {
+			edges[packages[names[j]]] += int(w)
+			total += int(w)
+		}
+		home := packages[name]
+		suggested := home
+		for _, pkg := range sortedKeys(edges) {
+			if edges[pkg] > edges[suggested] {
+				suggested = pkg
+			}
+		}
+		if suggested == home {
+			continue
+		}
+		report.Misplaced = append(report.Misplaced, MisplacedFunction{
+			Function:  name,
+			Package:   home,
+			Suggested: suggested,
+			Community: report.community[name],
+			Edges:     total,
+			Home:      edges[home],
+			Elsewhere: edges[suggested],
+		})
+	}
+	return report
+}
+
+// String formats the report as text.
+func (r *CommunityReport) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Modularity: %.3f\n", r.Modularity)
+	fmt.Fprintf(&buf, "Proposed packages (%d):\n", len(r.Communities))
+	for _, c := range r.Communities {
+		var from []string
+		for _, pkg := range sortedKeys(c.Packages) {
+			from = append(from, fmt.Sprintf("%s (%d)", pkg, c.Packages[pkg]))
+		}
+		fmt.Fprintf(&buf, "  %d: %s, %d functions from %s\n", c.ID, c.Proposed, len(c.Members), strings.Join(from, ", "))
+		fmt.Fprintf(&buf, "     %s\n", strings.Join(c.Members, ", "))
+	}
+
+	fmt.Fprintf(&buf, "Misplaced functions (%d):\n", len(r.Misplaced))
+	for _, m := range r.Misplaced {
+		fmt.Fprintf(&buf, "  %s in %s: %d of %d calls go to %s, %d stay\n", m.Function, m.Package, m.Elsewhere, m.Edges, m.Suggested, m.Home)
+	}
+	return buf.String()
+}
+
+// DOT renders every community as a cluster labelled with its proposed package.
+// Misplaced functions are highlighted.
+func (r *CommunityReport) DOT() []byte {
+	misplaced := make(map[string]bool)
+	for _, m := range r.Misplaced {
+		misplaced[m.Function] = true
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph G {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	buf.WriteString("    node [style=filled, fillcolor=lightgray, shape=rectangle];\n")
+	for _, c := range r.Communities {
+		buf.WriteString(fmt.Sprintf("    subgraph cluster_community_%d {\n", c.ID))
+		buf.WriteString("        style=filled;\n")
+		buf.WriteString("        color=\"#EBF5FB\";\n")
+		buf.WriteString(fmt.Sprintf("        label=\"%d: %s\";\n", c.ID, escapeStringForDOT(c.Proposed)))
+		for _, name := range c.Members {
+			color := "lightgray"
+			if misplaced[name] {
+				color = "#F5B7B1" // Light red
+			}
+			buf.WriteString(fmt.Sprintf("        \"%s\" [label=\"%s\", fillcolor=\"%s\"];\n", sanitizeIdentifier(name), escapeStringForDOT(name), color))
+		}
+		buf.WriteString("    }\n")
+	}
+	for _, name := range sortedKeys(r.community) {
+		for _, callee := range sortedKeys(r.graph.Nodes[name].Calls) {
+			if _, ok := r.community[callee]; ok && callee != name {
+				buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\";\n", sanitizeIdentifier(name), sanitizeIdentifier(callee)))
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// CommunitiesOptions configures a Communities run.
+type CommunitiesOptions struct {
+	Format string // Output format: text, json or dot.
+	Output string // Output file, stdout when empty.
+}
+
+// Communities proposes a package layout for the project from its call graph.
+func Communities(project string, opts CommunitiesOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	report := FindCommunities(p)
+	switch opts.Format {
+	case "", "text":
+		return writeOutput(opts.Output, []byte(report.String()))
+	case "json":
+		return writeJSON(opts.Output, report)
+	case "dot":
+		return writeOutput(opts.Output, report.DOT())
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
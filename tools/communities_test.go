@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindCommunities tests the FindCommunities function.
+func TestFindCommunities(t *testing.T) {
+	graph := newTestGraph(
+		Edge{"a1", "a2"}, Edge{"a2", "a3"}, Edge{"a3", "a1"}, Edge{"a1", "a4"}, Edge{"a4", "a2"},
+		Edge{"b1", "b2"}, Edge{"b2", "b3"}, Edge{"b3", "b1"}, Edge{"b1", "b4"}, Edge{"b4", "b2"},
+		Edge{"a1", "b1"},
+	)
+	for name, node := range graph.Nodes {
+		pkg := name[:1]
+		node.Info = &FunctionInfo{Name: name, PkgName: pkg, RelativeFilePath: pkg + "/" + pkg + ".go"}
+	}
+	// b4 only talks to package b but lives in package a.
+	graph.Nodes["b4"].Info = &FunctionInfo{Name: "b4", PkgName: "a", RelativeFilePath: "a/a.go"}
+	report := FindCommunities(&Project{Graph: graph})
+
+	tests := []struct {
+		proposed string
+		members  []string
+		packages map[string]int
+	}{
+		{proposed: "a", members: []string{"a1", "a2", "a3", "a4"}, packages: map[string]int{"a": 4}},
+		{proposed: "b", members: []string{"b1", "b2", "b3", "b4"}, packages: map[string]int{"a": 1, "b": 3}},
+	}
+	if len(report.Communities) != len(tests) {
+		t.Fatalf("FindCommunities() found %d communities, want %d: %+v", len(report.Communities), len(tests), report.Communities)
+	}
+	for i, tt := range tests {
+		c := report.Communities[i]
+		if c.Proposed != tt.proposed || !reflect.DeepEqual(c.Members, tt.members) || !reflect.DeepEqual(c.Packages, tt.packages) {
+			t.Errorf("community %d = %+v, want %s with %v from %v", i, c, tt.proposed, tt.members, tt.packages)
+		}
+	}
+	if report.Modularity <= 0.3 {
+		t.Errorf("Modularity = %.3f, want above 0.3 for two clear clusters", report.Modularity)
+	}
+
+	want := []MisplacedFunction{{Function: "b4", Package: "a", Suggested: "b", Community: 1, Edges: 2, Home: 0, Elsewhere: 2}}
+	if !reflect.DeepEqual(report.Misplaced, want) {
+		t.Errorf("Misplaced = %+v, want %+v", report.Misplaced, want)
+	}
+}
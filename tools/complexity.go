@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+)
+
+// setComplexity fills in the size and complexity metrics of a function.
+// src is the source of the file the declaration was parsed from.
+func setComplexity(fi *FunctionInfo, funcDecl *ast.FuncDecl, fset *token.FileSet, src []byte) {
+	fi.ParameterCount = len(fi.Parameters)
+	start := fset.Position(funcDecl.Pos()).Offset
+	end := fset.Position(funcDecl.End()).Offset
+	if start >= 0 && end <= len(src) && start < end {
+		fi.LinesOfCode = linesOfCode(src[start:end])
+	}
+	if funcDecl.Body == nil {
+		return
+	}
+
+	fi.CyclomaticComplexity = cyclomaticComplexity(funcDecl.Body)
+	c := &cognitiveCounter{funcName: funcDecl.Name.Name}
+	c.block(funcDecl.Body.List, 0)
+	fi.CognitiveComplexity = c.score
+	fi.MaxNesting = c.maxNesting
+
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit:
+			// Returns inside closures return from the closure, not the function.
+			return false
+		case *ast.ReturnStmt:
+			fi.ReturnCount++
+		}
+		return true
+	})
+}
+
+// linesOfCode counts the lines of src holding at least one token, ignoring
+// blank lines and comments.
+func linesOfCode(src []byte) int {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+	lines := make(map[int]bool)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			// Automatically inserted semicolon.
+			continue
+		}
+		lines[file.Line(pos)] = true
+	}
+	return len(lines)
+}
+
+// cyclomaticComplexity returns one plus the number of decision points in body.
+func cyclomaticComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// cognitiveCounter computes cognitive complexity: control flow structures
+// add one plus their nesting level, else branches, jumps to labels and
+// sequences of boolean operators add one and recursion adds one per call.
+type cognitiveCounter struct {
+	funcName   string
+	score      int
+	maxNesting int
+}
+
+func (c *cognitiveCounter) block(stmts []ast.Stmt, nesting int) {
+	for _, stmt := range stmts {
+		c.stmt(stmt, nesting)
+	}
+}
+
+func (c *cognitiveCounter) nest(nesting int) {
+	c.maxNesting = max(c.maxNesting, nesting)
+}
+
+func (c *cognitiveCounter) stmt(stmt ast.Stmt, nesting int) {
+	switch s := stmt.(type) {
+	case *ast.IfStmt:
+		c.score += 1 + nesting
+		c.ifChain(s, nesting)
+	case *ast.ForStmt:
+		c.score += 1 + nesting
+		c.nest(nesting + 1)
+		c.expr(s.Cond, nesting)
+		c.block(s.Body.List, nesting+1)
+	case *ast.RangeStmt:
+		c.score += 1 + nesting
+		c.nest(nesting + 1)
+		c.expr(s.X, nesting)
+		c.block(s.Body.List, nesting+1)
+	case *ast.SwitchStmt:
+		c.score += 1 + nesting
+		c.nest(nesting + 1)
+		c.expr(s.Tag, nesting)
+		for _, clause := range s.Body.List {
+			c.block(clause.(*ast.CaseClause).Body, nesting+1)
+		}
+	case *ast.TypeSwitchStmt:
+		c.score += 1 + nesting
+		c.nest(nesting + 1)
+		for _, clause := range s.Body.List {
+			c.block(clause.(*ast.CaseClause).Body, nesting+1)
+		}
+	case *ast.SelectStmt:
+		c.score += 1 + nesting
+		c.nest(nesting + 1)
+		for _, clause := range s.Body.List {
+			c.block(clause.(*ast.CommClause).Body, nesting+1)
+		}
+	case *ast.BranchStmt:
+		if s.Label != nil && s.Tok != token.FALLTHROUGH {
+			c.score++
+		}
+	case *ast.BlockStmt:
+		c.block(s.List, nesting)
+	case *ast.LabeledStmt:
+		c.stmt(s.Stmt, nesting)
+	default:
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if expr, ok := n.(ast.Expr); ok {
+				c.expr(expr, nesting)
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// ifChain scores the body of an if statement and its else branches.
+func (c *cognitiveCounter) ifChain(s *ast.IfStmt, nesting int) {
+	c.nest(nesting + 1)
+	if s.Init != nil {
+		c.stmt(s.Init, nesting)
+	}
+	c.expr(s.Cond, nesting)
+	c.block(s.Body.List, nesting+1)
+	switch e := s.Else.(type) {
+	case *ast.IfStmt:
+		c.score++
+		c.ifChain(e, nesting)
+	case *ast.BlockStmt:
+		c.score++
+		c.block(e.List, nesting+1)
+	}
+}
+
+// expr scores boolean operator sequences, recursive calls and closures in an expression.
+func (c *cognitiveCounter) expr(expr ast.Expr, nesting int) {
+	if expr == nil {
+		return
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			c.nest(nesting + 1)
+			c.block(n.Body.List, nesting+1)
+			return false
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				c.score += booleanSequences(n)
+				c.operands(n, nesting)
+				return false
+			}
+		case *ast.CallExpr:
+			if ident, ok := n.Fun.(*ast.Ident); ok && ident.Name == c.funcName {
+				c.score++
+			}
+		}
+		return true
+	})
+}
+
+// operands scores the non-boolean operands of a boolean expression.
+func (c *cognitiveCounter) operands(e *ast.BinaryExpr, nesting int) {
+	for _, operand := range []ast.Expr{e.X, e.Y} {
+		operand = ast.Unparen(operand)
+		if b, ok := operand.(*ast.BinaryExpr); ok && (b.Op == token.LAND || b.Op == token.LOR) {
+			c.operands(b, nesting)
+			continue
+		}
+		c.expr(operand, nesting)
+	}
+}
+
+// booleanSequences counts the runs of identical boolean operators in e, so
+// that a && b && c scores one and a && b || c scores two.
+func booleanSequences(e *ast.BinaryExpr) int {
+	var ops []token.Token
+	var flatten func(expr ast.Expr)
+	flatten = func(expr ast.Expr) {
+		b, ok := ast.Unparen(expr).(*ast.BinaryExpr)
+		if !ok || (b.Op != token.LAND && b.Op != token.LOR) {
+			return
+		}
+		flatten(b.X)
+		ops = append(ops, b.Op)
+		flatten(b.Y)
+	}
+	flatten(e)
+	sequences := 0
+	for i, op := range ops {
+		if i == 0 || ops[i-1] != op {
+			sequences++
+		}
+	}
+	return sequences
+}
+
+// ComplexityStyle colours function nodes by cyclomatic complexity.
+func ComplexityStyle(node *FunctionNode) map[string]string {
+	fi := node.Info
+	if fi == nil {
+		return nil
+	}
+	color := "#D5F5E3" // Light green
+	switch {
+	case fi.CyclomaticComplexity > 20:
+		color = "#F1948A" // Red
+	case fi.CyclomaticComplexity > 10:
+		color = "#F5CBA7" // Orange
+	case fi.CyclomaticComplexity > 5:
+		color = "#F9E79F" // Yellow
+	}
+	return map[string]string{
+		"fillcolor": color,
+		"tooltip": fmt.Sprintf("LOC %d, cyclomatic %d, cognitive %d, nesting %d",
+			fi.LinesOfCode, fi.CyclomaticComplexity, fi.CognitiveComplexity, fi.MaxNesting),
+	}
+}
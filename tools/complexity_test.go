@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestComplexity tests the metrics parseFile records for each function.
+func TestComplexity(t *testing.T) {
+	src := `package sample
+
+func straight(a int) int {
+	// Comments and blank lines are not code.
+
+	return a
+}
+
+func branches(items []int, limit int) (int, error) {
+	total := 0
+	for _, item := range items { // +1 cyclomatic, +1 cognitive
+		if item > limit && limit > 0 { // +2 cyclomatic, +2 (nesting) +1 (&&) cognitive
+			return 0, nil
+		} else if item < 0 || item == 0 { // +2 cyclomatic, +1 (else if) +1 (||) cognitive
+			continue
+		}
+		total += item
+	}
+	switch { // +1 cognitive
+	case total > 10: // +1 cyclomatic
+		return total, nil
+	default:
+	}
+	return total, nil
+}
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sample.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	functions, err := parseFile("sample.go", path)
+	if err != nil {
+		t.Fatalf("parseFile() error = %v", err)
+	}
+
+	type metrics struct{ loc, cyclomatic, cognitive, nesting, returns, params int }
+	want := map[string]metrics{
+		"straight": {loc: 3, cyclomatic: 1, cognitive: 0, nesting: 0, returns: 1, params: 1},
+		"branches": {loc: 17, cyclomatic: 7, cognitive: 7, nesting: 2, returns: 3, params: 2},
+	}
+	for _, fi := range functions {
+		got := metrics{fi.LinesOfCode, fi.CyclomaticComplexity, fi.CognitiveComplexity, fi.MaxNesting, fi.ReturnCount, fi.ParameterCount}
+		if got != want[fi.Name] {
+			t.Errorf("%s metrics = %+v, want %+v", fi.Name, got, want[fi.Name])
+		}
+	}
+}
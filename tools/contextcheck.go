@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"text/tabwriter"
+)
+
+// ContextBreak is a call that does not pass the caller's context along.
+type ContextBreak struct {
+	Function string `json:"function"`
+	Callee   string `json:"callee"`
+	Kind     string `json:"kind"` // background, todo or missing.
+	Argument string `json:"argument"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// contextParam returns the index of the context.Context parameter of a function, or -1.
+func contextParam(fi *FunctionInfo) int {
+	for i, param := range fi.Parameters {
+		if param.Type == "context.Context" || (param.ImportPath == "context" && strings.HasSuffix(param.Type, ".Context")) {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindContextBreaks reports calls that break the context chain: functions with
+// a context parameter that pass context.Background() or context.TODO() instead,
+// and functions without a context parameter calling functions that need one.
+// main and init functions are roots of the chain and are not reported for
+// lacking a context.
+func FindContextBreaks(p *Project) ([]ContextBreak, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var breaks []ContextBreak
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			caller := funcDeclFullName(fd)
+			node, ok := p.Graph.Nodes[caller]
+			if !ok || node.Info == nil {
+				continue
+			}
+			hasContext := contextParam(node.Info) >= 0
+			isRoot := fd.Recv == nil && (fd.Name.Name == "main" || fd.Name.Name == "init")
+
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				callExpr, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				call := extractCallInfo(callExpr, sf.Fset, sf.RelPath, sf.importSet)
+				brk := ContextBreak{
+					Function: caller,
+					Callee:   getCallFullName(call),
+					File:     sf.RelPath,
+					Line:     call.Line,
+				}
+
+				if hasContext {
+					for _, arg := range call.Arguments {
+						if kind := freshContextKind(arg); kind != "" {
+							brk.Kind, brk.Argument = kind, arg
+							breaks = append(breaks, brk)
+							break
+						}
+					}
+					return true
+				}
+
+				if isRoot {
+					return true
+				}
+				for _, fi := range p.calleeInfos(sf, callExpr) {
+					i := contextParam(fi)
+					if i < 0 || i >= len(call.Arguments) {
+						continue
+					}
+					brk.Kind, brk.Argument = "missing", call.Arguments[i]
+					breaks = append(breaks, brk)
+					break
+				}
+				return true
+			})
+		}
+	}
+	return breaks, nil
+}
+
+// freshContextKind reports whether an argument creates a new root context.
+func freshContextKind(arg string) string {
+	switch strings.ReplaceAll(arg, " ", "") {
+	case "context.Background()":
+		return "background"
+	case "context.TODO()":
+		return "todo"
+	}
+	return ""
+}
+
+// ContextCheckOptions configures a ContextCheck run.
+type ContextCheckOptions struct {
+	Format string // Output format: text or json.
+	Output string // Output file, stdout when empty.
+}
+
+// ContextCheck reports the breaks in the context chain of the project.
+func ContextCheck(project string, opts ContextCheckOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	breaks, err := FindContextBreaks(p)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "text":
+		var buf strings.Builder
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tFUNCTION\tCALLEE\tARGUMENT\tLOCATION")
+		for _, b := range breaks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s:%d\n", b.Kind, b.Function, b.Callee, b.Argument, b.File, b.Line)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "%d breaks in the context chain\n", len(breaks))
+		return writeOutput(opts.Output, []byte(buf.String()))
+	case "json":
+		return writeJSON(opts.Output, breaks)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
+
+// checkContextChain reports breaks in the context chain as rule violations.
+func checkContextChain(p *Project) ([]Violation, error) {
+	breaks, err := FindContextBreaks(p)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for _, b := range breaks {
+		msg := fmt.Sprintf("%s is called with %s instead of the caller's context", b.Callee, b.Argument)
+		if b.Kind == "missing" {
+			msg = fmt.Sprintf("%s needs a context but the caller has none", b.Callee)
+		}
+		violations = append(violations, Violation{Function: b.Function, File: b.File, Line: b.Line, Message: msg})
+	}
+	return violations, nil
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindContextBreaks tests the FindContextBreaks function.
+func TestFindContextBreaks(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"sample.go": `package sample
+
+import "context"
+
+func main() {
+	fetch(context.Background())
+}
+
+func handle(ctx context.Context) {
+	fetch(ctx)
+	fetch(context.Background())
+	fetch(context.TODO())
+}
+
+func fetch(ctx context.Context) {}
+
+func sync() {
+	fetch(context.Background())
+}
+`,
+	})
+	breaks, err := FindContextBreaks(p)
+	if err != nil {
+		t.Fatalf("FindContextBreaks() error = %v", err)
+	}
+	// main is the root of the chain, and handle passes its own context first.
+	want := []ContextBreak{
+		{Function: "handle", Callee: "fetch", Kind: "background", Argument: "context.Background()", File: "sample.go", Line: 11},
+		{Function: "handle", Callee: "fetch", Kind: "todo", Argument: "context.TODO()", File: "sample.go", Line: 12},
+		{Function: "sync", Callee: "fetch", Kind: "missing", Argument: "context.Background()", File: "sample.go", Line: 18},
+	}
+	if !reflect.DeepEqual(breaks, want) {
+		t.Errorf("FindContextBreaks() = %+v, want %+v", breaks, want)
+	}
+}
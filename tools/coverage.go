@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// CoverBlock is a block of statements of a go test -coverprofile file.
+type CoverBlock struct {
+	File       string // Import path of the package followed by the file name.
+	StartLine  int
+	StartCol   int
+	EndLine    int
+	EndCol     int
+	Statements int
+	Count      int
+}
+
+// ParseCoverProfile reads a coverage profile written by go test -coverprofile.
+// Blocks reported more than once, as happens with -coverpkg, keep the highest count.
+func ParseCoverProfile(filename string) ([]CoverBlock, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	blocks := make(map[string]*CoverBlock)
+	var order []string
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		block, err := parseCoverLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, lineNumber, err)
+		}
+		key := strings.Fields(line)[0]
+		if existing, ok := blocks[key]; ok {
+			existing.Count = max(existing.Count, block.Count)
+			continue
+		}
+		blocks[key] = &block
+		order = append(order, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	result := make([]CoverBlock, 0, len(order))
+	for _, key := range order {
+		result = append(result, *blocks[key])
+	}
+	return result, nil
+}
+
+// parseCoverLine parses a profile line such as "example.com/m/a.go:3.14,5.2 1 0".
+func parseCoverLine(line string) (CoverBlock, error) {
+	var b CoverBlock
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return b, fmt.Errorf("invalid coverage line %q", line)
+	}
+	colon := strings.LastIndex(fields[0], ":")
+	if colon < 0 {
+		return b, fmt.Errorf("invalid coverage block %q", fields[0])
+	}
+	b.File = fields[0][:colon]
+	if _, err := fmt.Sscanf(fields[0][colon+1:], "%d.%d,%d.%d", &b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol); err != nil {
+		return b, fmt.Errorf("invalid coverage block %q: %w", fields[0], err)
+	}
+	var err error
+	if b.Statements, err = strconv.Atoi(fields[1]); err != nil {
+		return b, fmt.Errorf("invalid statement count %q", fields[1])
+	}
+	if b.Count, err = strconv.Atoi(fields[2]); err != nil {
+		return b, fmt.Errorf("invalid hit count %q", fields[2])
+	}
+	return b, nil
+}
+
+// FunctionCoverage is the statement coverage of a function.
+type FunctionCoverage struct {
+	Function    string  `json:"function"`
+	File        string  `json:"file"`
+	Line        int     `json:"line"`
+	Statements  int     `json:"statements"`
+	Covered     int     `json:"covered"`
+	Entrypoints int     `json:"entrypoints"` // Entrypoints reaching the function.
+	Percent     float64 `json:"percent"`
+}
+
+// Coverage is the statement coverage of the functions of a project, keyed by
+// file and first line so functions with the same name stay apart.
+type Coverage map[string]*FunctionCoverage
+
+func coverageKey(file string, line int) string {
+	return filepath.ToSlash(file) + ":" + strconv.Itoa(line)
+}
+
+// ComputeCoverage attributes the profile blocks to the functions of the
+// project. Profile files are matched by import path, using the module path of
+// the nearest go.mod, or failing that by their path suffix.
+func ComputeCoverage(p *Project, blocks []CoverBlock) Coverage {
+	byFile := make(map[string][]CoverBlock)
+	for _, b := range blocks {
+		byFile[b.File] = append(byFile[b.File], b)
+	}
+
+	reach := make(map[string]int)
+	for _, entry := range FindEntrypoints(p) {
+		for name := range reachableFrom(entry) {
+			reach[name]++
+		}
+	}
+
+	// Profile files matched by suffix go to the project file with the longest
+	// matching path, so that main.go does not take the blocks of cmd/a/main.go.
+	var rels []string
+	for _, fi := range p.Functions {
+		rels = append(rels, filepath.ToSlash(fi.RelativeFilePath))
+	}
+	bySuffix := make(map[string][]CoverBlock)
+	for _, file := range sortedKeys(byFile) {
+		if rel := longestPathSuffix(file, rels); rel != "" && bySuffix[rel] == nil {
+			bySuffix[rel] = byFile[file]
+		}
+	}
+
+	coverage := make(Coverage)
+	for _, fi := range p.Functions {
+		fileBlocks, ok := byFile[importFile(p.Root, fi.RelativeFilePath)]
+		if !ok {
+			fileBlocks = bySuffix[filepath.ToSlash(fi.RelativeFilePath)]
+		}
+		name := getFunctionFullName(fi)
+		fc := &FunctionCoverage{Function: name, File: fi.RelativeFilePath, Line: fi.LineNumberStart, Entrypoints: reach[name]}
+		for _, b := range fileBlocks {
+			if b.StartLine >= fi.LineNumberStart && b.StartLine <= fi.LineNumberEnd {
+				fc.Statements += b.Statements
+				if b.Count > 0 {
+					fc.Covered += b.Statements
+				}
+			}
+		}
+		if fc.Statements > 0 {
+			fc.Percent = 100 * float64(fc.Covered) / float64(fc.Statements)
+		}
+		coverage[coverageKey(fi.RelativeFilePath, fi.LineNumberStart)] = fc
+	}
+	return coverage
+}
+
+// longestPathSuffix returns the longest of the slash-separated paths that file
+// is or ends with, or "" when it matches none.
+func longestPathSuffix(file string, paths []string) string {
+	var best string
+	for _, path := range paths {
+		if len(path) > len(best) && (file == path || strings.HasSuffix(file, "/"+path)) {
+			best = path
+		}
+	}
+	return best
+}
+
+// importFile returns the name a coverage profile uses for a project file: the
+// module path joined with the file's path inside the module.
+func importFile(projectRoot, relPath string) string {
+	abs, err := filepath.Abs(filepath.Join(projectRoot, relPath))
+	if err != nil {
+		return ""
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if module := readModulePath(filepath.Join(dir, "go.mod")); module != "" {
+			inside, err := filepath.Rel(dir, abs)
+			if err != nil {
+				return ""
+			}
+			return module + "/" + filepath.ToSlash(inside)
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// Uncovered returns the functions with statements but no coverage, the ones
+// reached by the most entrypoints first.
+func (c Coverage) Uncovered() []*FunctionCoverage {
+	var uncovered []*FunctionCoverage
+	for _, fc := range c {
+		if fc.Statements > 0 && fc.Covered == 0 {
+			uncovered = append(uncovered, fc)
+		}
+	}
+	sort.Slice(uncovered, func(i, j int) bool {
+		a, b := uncovered[i], uncovered[j]
+		if a.Entrypoints != b.Entrypoints {
+			return a.Entrypoints > b.Entrypoints
+		}
+		if a.Statements != b.Statements {
+			return a.Statements > b.Statements
+		}
+		return a.Function < b.Function
+	})
+	return uncovered
+}
+
+// Report formats the total coverage and the uncovered functions as text.
+func (c Coverage) Report() (string, error) {
+	var statements, covered int
+	for _, fc := range c {
+		statements += fc.Statements
+		covered += fc.Covered
+	}
+	var buf strings.Builder
+	if statements > 0 {
+		fmt.Fprintf(&buf, "Statement coverage: %.1f%% (%d of %d)\n", 100*float64(covered)/float64(statements), covered, statements)
+	}
+	uncovered := c.Uncovered()
+	fmt.Fprintf(&buf, "Uncovered functions (%d):\n", len(uncovered))
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ENTRYPOINTS\tFUNCTION\tSTATEMENTS\tLOCATION")
+	for _, fc := range uncovered {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s:%d\n", fc.Entrypoints, fc.Function, fc.Statements, fc.File, fc.Line)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// CoverageStyle colours function nodes by statement coverage.
+func CoverageStyle(c Coverage) NodeStyle {
+	return func(node *FunctionNode) map[string]string {
+		if node.Info == nil {
+			return nil
+		}
+		fc, ok := c[coverageKey(node.Info.RelativeFilePath, node.Info.LineNumberStart)]
+		if !ok || fc.Statements == 0 {
+			return nil
+		}
+		color := "#F1948A" // Red
+		switch {
+		case fc.Percent >= 80:
+			color = "#82E0AA" // Green
+		case fc.Percent >= 50:
+			color = "#F9E79F" // Yellow
+		case fc.Percent > 0:
+			color = "#F5CBA7" // Orange
+		}
+		return map[string]string{
+			"fillcolor": color,
+			"tooltip":   fmt.Sprintf("coverage %.0f%% (%d of %d statements)", fc.Percent, fc.Covered, fc.Statements),
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestComputeCoverage tests the ParseCoverProfile and ComputeCoverage functions.
+func TestComputeCoverage(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/sample\n",
+		"sample.go": `package sample
+
+func Exported(x int) int {
+	if x > 0 {
+		return helper(x)
+	}
+	return 0
+}
+
+func helper(x int) int {
+	return x * 2
+}
+
+func Untested() int {
+	return 1
+}
+`,
+		"cover.out": `mode: set
+example.com/sample/sample.go:3.26,4.11 1 1
+example.com/sample/sample.go:4.11,6.3 1 0
+example.com/sample/sample.go:7.2,7.10 1 1
+example.com/sample/sample.go:10.24,12.2 1 0
+example.com/sample/sample.go:14.22,16.2 1 0
+example.com/sample/sample.go:10.24,12.2 1 1
+`,
+	}
+	p := loadTestProject(t, files)
+	blocks, err := ParseCoverProfile(filepath.Join(p.Root, "cover.out"))
+	if err != nil {
+		t.Fatalf("ParseCoverProfile() error = %v", err)
+	}
+	if len(blocks) != 5 {
+		t.Fatalf("ParseCoverProfile() returned %d blocks, want 5 after merging duplicates", len(blocks))
+	}
+
+	coverage := ComputeCoverage(p, blocks)
+	tests := []struct {
+		function    string
+		line        int
+		statements  int
+		covered     int
+		entrypoints int
+	}{
+		{function: "Exported", line: 3, statements: 3, covered: 2, entrypoints: 1},
+		{function: "helper", line: 10, statements: 1, covered: 1, entrypoints: 1},
+		{function: "Untested", line: 14, statements: 1, covered: 0, entrypoints: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			fc, ok := coverage[coverageKey("sample.go", tt.line)]
+			if !ok {
+				t.Fatalf("no coverage for %s", tt.function)
+			}
+			if fc.Function != tt.function || fc.Statements != tt.statements || fc.Covered != tt.covered || fc.Entrypoints != tt.entrypoints {
+				t.Errorf("coverage = %+v, want %d of %d statements reached by %d entrypoints",
+					fc, tt.covered, tt.statements, tt.entrypoints)
+			}
+		})
+	}
+
+	uncovered := coverage.Uncovered()
+	if len(uncovered) != 1 || uncovered[0].Function != "Untested" {
+		t.Errorf("Uncovered() = %v, want [Untested]", uncovered)
+	}
+}
+
+// TestComputeCoverageBySuffix tests that ComputeCoverage matches profile files
+// outside the module by their longest path suffix.
+func TestComputeCoverageBySuffix(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"main.go": `package main
+
+func main() {
+	start()
+}
+
+func start() {}
+`,
+		"cmd/a/main.go": `package main
+
+func helper() int {
+	return 1
+}
+`,
+		"cover.out": `mode: set
+github.com/other/repo/main.go:3.13,5.2 1 1
+github.com/other/repo/cmd/a/main.go:3.19,5.2 1 0
+`,
+	})
+	blocks, err := ParseCoverProfile(filepath.Join(p.Root, "cover.out"))
+	if err != nil {
+		t.Fatalf("ParseCoverProfile() error = %v", err)
+	}
+
+	coverage := ComputeCoverage(p, blocks)
+	tests := []struct {
+		file     string
+		function string
+		covered  int
+	}{
+		{file: "main.go", function: "main", covered: 1},
+		{file: "cmd/a/main.go", function: "helper", covered: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			fc, ok := coverage[coverageKey(tt.file, 3)]
+			if !ok {
+				t.Fatalf("no coverage for %s", tt.file)
+			}
+			if fc.Function != tt.function || fc.Statements != 1 || fc.Covered != tt.covered {
+				t.Errorf("coverage = %+v, want %s with %d of 1 statements", fc, tt.function, tt.covered)
+			}
+		})
+	}
+}
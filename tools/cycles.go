@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"sort"
+	"strings"
+)
+
+// FindCycles returns the strongly connected components of the graph that form
+// cycles, i.e. components with more than one function or a function calling
+// itself. Each cycle is sorted by name and the cycles are sorted by their first
+// member.
+func FindCycles(graph *CallGraph) [][]string {
+	index := 0
+	indices := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var strongConnect func(name string)
+	strongConnect = func(name string) {
+		indices[name] = index
+		lowLinks[name] = index
+		index++
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, callee := range sortedKeys(graph.Nodes[name].Calls) {
+			if _, visited := indices[callee]; !visited {
+				strongConnect(callee)
+				lowLinks[name] = min(lowLinks[name], lowLinks[callee])
+			} else if onStack[callee] {
+				lowLinks[name] = min(lowLinks[name], indices[callee])
+			}
+		}
+
+		if lowLinks[name] != indices[name] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		_, selfLoop := graph.Nodes[name].Calls[name]
+		if len(component) > 1 || selfLoop {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, name := range sortedKeys(graph.Nodes) {
+		if _, visited := indices[name]; !visited {
+			strongConnect(name)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// cycleKey returns a string identifying a cycle by its members.
+func cycleKey(cycle []string) string {
+	return strings.Join(cycle, "\x00")
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for name := range m {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
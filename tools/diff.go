@@ -0,0 +1,427 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Edge is a call from one function to another.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SignatureChange records a function whose parameters or returns changed.
+type SignatureChange struct {
+	Function string `json:"function"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+}
+
+// GraphDiff describes how the call graph changed between two revisions.
+type GraphDiff struct {
+	Old               string            `json:"old"`
+	New               string            `json:"new"`
+	AddedFunctions    []string          `json:"addedFunctions"`
+	RemovedFunctions  []string          `json:"removedFunctions"`
+	ChangedSignatures []SignatureChange `json:"changedSignatures"`
+	AddedEdges        []Edge            `json:"addedEdges"`
+	RemovedEdges      []Edge            `json:"removedEdges"`
+	NewCycles         [][]string        `json:"newCycles"`
+
+	oldGraph *CallGraph
+	newGraph *CallGraph
+}
+
+// DiffOptions configures a Diff run.
+type DiffOptions struct {
+	Src    string // Project directory inside the git repository used to resolve revisions.
+	Format string // Output format: text, json, dot or html.
+	Output string // Output file, stdout when empty.
+}
+
+// Diff analyses two revisions and reports how the call graph changed. Each
+// revision is either a source directory or a git revision, which is checked out
+// into a temporary worktree.
+func Diff(oldRev, newRev string, opts DiffOptions) error {
+	oldDir, cleanupOld, err := prepareRevision(oldRev, opts.Src)
+	if err != nil {
+		return err
+	}
+	defer cleanupOld()
+	newDir, cleanupNew, err := prepareRevision(newRev, opts.Src)
+	if err != nil {
+		return err
+	}
+	defer cleanupNew()
+
+	oldProject, err := LoadProject(oldDir)
+	if err != nil {
+		return fmt.Errorf("failed to analyze %s: %w", oldRev, err)
+	}
+	newProject, err := LoadProject(newDir)
+	if err != nil {
+		return fmt.Errorf("failed to analyze %s: %w", newRev, err)
+	}
+
+	diff := DiffProjects(oldProject, newProject)
+	diff.Old = oldRev
+	diff.New = newRev
+
+	switch opts.Format {
+	case "", "text":
+		return writeOutput(opts.Output, []byte(diff.String()))
+	case "json":
+		return writeJSON(opts.Output, diff)
+	case "dot":
+		return writeOutput(opts.Output, diff.DOT())
+	case "html":
+		return writeHTML(diff.htmlGraph(), opts.Output)
+	default:
+		return fmt.Errorf("unknown diff format: %s", opts.Format)
+	}
+}
+
+// prepareRevision returns a directory holding the source of rev. Directories are
+// used as they are, anything else is checked out as a git revision of the
+// repository containing src.
+func prepareRevision(rev, src string) (string, func(), error) {
+	if info, err := os.Stat(rev); err == nil && info.IsDir() {
+		return rev, func() {}, nil
+	}
+	if src == "" {
+		src = "."
+	}
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return "", nil, err
+	}
+	top, err := runGit(absSrc, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", nil, err
+	}
+	rel, err := filepath.Rel(top, absSrc)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "gpa-diff-")
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := runGit(top, "worktree", "add", "--detach", tmp, rev); err != nil {
+		os.RemoveAll(tmp)
+		return "", nil, err
+	}
+	cleanup := func() {
+		if _, err := runGit(top, "worktree", "remove", "--force", tmp); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to remove worktree %s: %v\n", tmp, err)
+		}
+		os.RemoveAll(tmp)
+	}
+	return filepath.Join(tmp, rel), cleanup, nil
+}
+
+// runGit runs a git command in dir and returns its trimmed output.
+func runGit(dir string, args ...string) (string, error) {
+	out, err := gitOutput(dir, args...)
+	return strings.TrimSpace(out), err
+}
+
+// gitOutput runs a git command in dir and returns its output as is, for file
+// contents whose leading blank lines count.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// gitBlobs reads objects such as "<commit>:<path>" through one git cat-file
+// --batch process, instead of starting git once per object.
+type gitBlobs struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+// newGitBlobs starts git cat-file --batch in dir.
+func newGitBlobs(dir string) (*gitBlobs, error) {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = dir
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w", err)
+	}
+	return &gitBlobs{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+// read returns the content of an object as is.
+func (b *gitBlobs) read(object string) (string, error) {
+	if _, err := fmt.Fprintln(b.in, object); err != nil {
+		return "", err
+	}
+	// The header is "<oid> <type> <size>", or "<object> missing".
+	header, err := b.out.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("git cat-file %s: %w", object, err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return "", fmt.Errorf("git cat-file %s: %s", object, strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", fmt.Errorf("git cat-file %s: invalid size %s", object, fields[2])
+	}
+	// The content is followed by a newline.
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(b.out, content); err != nil {
+		return "", fmt.Errorf("git cat-file %s: %w", object, err)
+	}
+	return string(content[:size]), nil
+}
+
+// Close stops the git process.
+func (b *gitBlobs) Close() error {
+	if err := b.in.Close(); err != nil {
+		return err
+	}
+	return b.cmd.Wait()
+}
+
+// DiffProjects compares the functions, edges and cycles of two analysed projects.
+func DiffProjects(oldProject, newProject *Project) *GraphDiff {
+	diff := &GraphDiff{oldGraph: oldProject.Graph, newGraph: newProject.Graph}
+
+	oldFuncs := projectFunctions(oldProject.Graph)
+	newFuncs := projectFunctions(newProject.Graph)
+	for name, fi := range newFuncs {
+		old, ok := oldFuncs[name]
+		if !ok {
+			diff.AddedFunctions = append(diff.AddedFunctions, name)
+			continue
+		}
+		if oldSig, newSig := functionSignature(*old), functionSignature(*fi); oldSig != newSig {
+			diff.ChangedSignatures = append(diff.ChangedSignatures, SignatureChange{Function: name, Old: oldSig, New: newSig})
+		}
+	}
+	for name := range oldFuncs {
+		if _, ok := newFuncs[name]; !ok {
+			diff.RemovedFunctions = append(diff.RemovedFunctions, name)
+		}
+	}
+	sort.Strings(diff.AddedFunctions)
+	sort.Strings(diff.RemovedFunctions)
+	sort.Slice(diff.ChangedSignatures, func(i, j int) bool {
+		return diff.ChangedSignatures[i].Function < diff.ChangedSignatures[j].Function
+	})
+
+	diff.AddedEdges = edgesNotIn(newProject.Graph, oldProject.Graph)
+	diff.RemovedEdges = edgesNotIn(oldProject.Graph, newProject.Graph)
+
+	oldCycles := make(map[string]bool)
+	for _, cycle := range FindCycles(oldProject.Graph) {
+		oldCycles[cycleKey(cycle)] = true
+	}
+	for _, cycle := range FindCycles(newProject.Graph) {
+		if !oldCycles[cycleKey(cycle)] {
+			diff.NewCycles = append(diff.NewCycles, cycle)
+		}
+	}
+	return diff
+}
+
+// projectFunctions returns the nodes declared in the project keyed by name.
+func projectFunctions(graph *CallGraph) map[string]*FunctionInfo {
+	funcs := make(map[string]*FunctionInfo)
+	for name, node := range graph.Nodes {
+		if node.Info != nil {
+			funcs[name] = node.Info
+		}
+	}
+	return funcs
+}
+
+// edgesNotIn returns the edges of a that are missing from b, sorted.
+func edgesNotIn(a, b *CallGraph) []Edge {
+	var edges []Edge
+	for _, name := range sortedKeys(a.Nodes) {
+		for _, callee := range sortedKeys(a.Nodes[name].Calls) {
+			if other, ok := b.Nodes[name]; ok {
+				if _, ok := other.Calls[callee]; ok {
+					continue
+				}
+			}
+			edges = append(edges, Edge{From: name, To: callee})
+		}
+	}
+	return edges
+}
+
+// functionSignature formats the parameters and returns of a function.
+func functionSignature(fi FunctionInfo) string {
+	var params, returns []string
+	for _, p := range fi.Parameters {
+		params = append(params, strings.TrimSpace(p.Name+" "+p.Type))
+	}
+	for _, r := range fi.Returns {
+		returns = append(returns, r.Type)
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	switch len(returns) {
+	case 0:
+	case 1:
+		sig += " " + returns[0]
+	default:
+		sig += " (" + strings.Join(returns, ", ") + ")"
+	}
+	return sig
+}
+
+// String formats the diff as a text report.
+func (d *GraphDiff) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Comparing %s..%s\n", d.Old, d.New)
+	fmt.Fprintf(&buf, "Added functions (%d):\n", len(d.AddedFunctions))
+	for _, name := range d.AddedFunctions {
+		fmt.Fprintf(&buf, "  + %s\n", name)
+	}
+	fmt.Fprintf(&buf, "Removed functions (%d):\n", len(d.RemovedFunctions))
+	for _, name := range d.RemovedFunctions {
+		fmt.Fprintf(&buf, "  - %s\n", name)
+	}
+	fmt.Fprintf(&buf, "Changed signatures (%d):\n", len(d.ChangedSignatures))
+	for _, c := range d.ChangedSignatures {
+		fmt.Fprintf(&buf, "  ~ %s%s -> %s%s\n", c.Function, c.Old, c.Function, c.New)
+	}
+	fmt.Fprintf(&buf, "Added edges (%d):\n", len(d.AddedEdges))
+	for _, e := range d.AddedEdges {
+		fmt.Fprintf(&buf, "  + %s -> %s\n", e.From, e.To)
+	}
+	fmt.Fprintf(&buf, "Removed edges (%d):\n", len(d.RemovedEdges))
+	for _, e := range d.RemovedEdges {
+		fmt.Fprintf(&buf, "  - %s -> %s\n", e.From, e.To)
+	}
+	fmt.Fprintf(&buf, "New cycles (%d):\n", len(d.NewCycles))
+	for _, cycle := range d.NewCycles {
+		fmt.Fprintf(&buf, "  * %s\n", strings.Join(cycle, ", "))
+	}
+	return buf.String()
+}
+
+const (
+	diffAddedColor   = "#A9DFBF" // Light green
+	diffRemovedColor = "#F5B7B1" // Light red
+	diffChangedColor = "#FAD7A0" // Light orange
+)
+
+// changeSets returns the node colours and edge colours of the diff view. Only
+// changed functions, the endpoints of changed edges and the unchanged edges
+// between them are included so the view stays focused on the change.
+func (d *GraphDiff) changeSets() (nodes map[string]string, edges map[Edge]string) {
+	nodes = make(map[string]string)
+	edges = make(map[Edge]string)
+	for _, name := range d.AddedFunctions {
+		nodes[name] = diffAddedColor
+	}
+	for _, name := range d.RemovedFunctions {
+		nodes[name] = diffRemovedColor
+	}
+	for _, c := range d.ChangedSignatures {
+		nodes[c.Function] = diffChangedColor
+	}
+	for _, e := range d.AddedEdges {
+		edges[e] = "green"
+	}
+	for _, e := range d.RemovedEdges {
+		edges[e] = "red"
+	}
+	for e := range edges {
+		for _, name := range []string{e.From, e.To} {
+			if _, ok := nodes[name]; !ok {
+				nodes[name] = "lightgray"
+			}
+		}
+	}
+	if d.newGraph != nil {
+		for name := range nodes {
+			node, ok := d.newGraph.Nodes[name]
+			if !ok {
+				continue
+			}
+			for callee := range node.Calls {
+				e := Edge{From: name, To: callee}
+				if _, ok := nodes[callee]; ok && edges[e] == "" {
+					edges[e] = "gray50"
+				}
+			}
+		}
+	}
+	return nodes, edges
+}
+
+// DOT renders the diff as a DOT graph with additions in green and removals in red.
+func (d *GraphDiff) DOT() []byte {
+	nodes, edges := d.changeSets()
+	var buf bytes.Buffer
+	buf.WriteString("digraph G {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	buf.WriteString("    node [style=filled, shape=rectangle];\n")
+	for _, name := range sortedKeys(nodes) {
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", fillcolor=\"%s\"];\n",
+			sanitizeIdentifier(name), escapeStringForDOT(name), nodes[name]))
+	}
+	for _, e := range sortedEdges(edges) {
+		buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\" [color=\"%s\"];\n",
+			sanitizeIdentifier(e.From), sanitizeIdentifier(e.To), edges[e]))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func (d *GraphDiff) htmlGraph() htmlGraph {
+	nodes, edges := d.changeSets()
+	g := htmlGraph{Title: fmt.Sprintf("Call graph diff %s..%s", d.Old, d.New)}
+	for _, name := range sortedKeys(nodes) {
+		g.Nodes = append(g.Nodes, htmlNode{ID: name, Label: name, Color: nodes[name]})
+	}
+	for _, e := range sortedEdges(edges) {
+		g.Edges = append(g.Edges, htmlEdge{From: e.From, To: e.To, Color: edges[e], Dashed: edges[e] == "red"})
+	}
+	return g
+}
+
+func sortedEdges[V any](m map[Edge]V) []Edge {
+	edges := make([]Edge, 0, len(m))
+	for e := range m {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
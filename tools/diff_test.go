@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestGraph builds a call graph whose nodes are all project functions.
+func newTestGraph(edges ...Edge) *CallGraph {
+	graph := &CallGraph{Nodes: make(map[string]*FunctionNode)}
+	node := func(name string) *FunctionNode {
+		if n, ok := graph.Nodes[name]; ok {
+			return n
+		}
+		n := &FunctionNode{
+			Name:     name,
+			Calls:    make(map[string]*FunctionNode),
+			CalledBy: make(map[string]*FunctionNode),
+			Info:     &FunctionInfo{Name: name},
+		}
+		graph.Nodes[name] = n
+		return n
+	}
+	for _, e := range edges {
+		from, to := node(e.From), node(e.To)
+		from.Calls[e.To] = to
+		to.CalledBy[e.From] = from
+	}
+	return graph
+}
+
+// TestDiffProjects tests the DiffProjects function.
+func TestDiffProjects(t *testing.T) {
+	oldGraph := newTestGraph(Edge{"main", "load"}, Edge{"load", "parse"}, Edge{"main", "legacy"})
+	newGraph := newTestGraph(Edge{"main", "load"}, Edge{"load", "parse"}, Edge{"parse", "load"}, Edge{"main", "render"})
+	oldGraph.Nodes["parse"].Info.Parameters = []ParameterInfo{{Name: "src", Type: "string"}}
+	newGraph.Nodes["parse"].Info.Parameters = []ParameterInfo{{Name: "src", Type: "[]byte"}}
+
+	diff := DiffProjects(&Project{Graph: oldGraph}, &Project{Graph: newGraph})
+
+	if want := []string{"render"}; !reflect.DeepEqual(diff.AddedFunctions, want) {
+		t.Errorf("AddedFunctions = %v, want %v", diff.AddedFunctions, want)
+	}
+	if want := []string{"legacy"}; !reflect.DeepEqual(diff.RemovedFunctions, want) {
+		t.Errorf("RemovedFunctions = %v, want %v", diff.RemovedFunctions, want)
+	}
+	if want := []SignatureChange{{Function: "parse", Old: "(src string)", New: "(src []byte)"}}; !reflect.DeepEqual(diff.ChangedSignatures, want) {
+		t.Errorf("ChangedSignatures = %v, want %v", diff.ChangedSignatures, want)
+	}
+	if want := []Edge{{"main", "render"}, {"parse", "load"}}; !reflect.DeepEqual(diff.AddedEdges, want) {
+		t.Errorf("AddedEdges = %v, want %v", diff.AddedEdges, want)
+	}
+	if want := []Edge{{"main", "legacy"}}; !reflect.DeepEqual(diff.RemovedEdges, want) {
+		t.Errorf("RemovedEdges = %v, want %v", diff.RemovedEdges, want)
+	}
+	if want := [][]string{{"load", "parse"}}; !reflect.DeepEqual(diff.NewCycles, want) {
+		t.Errorf("NewCycles = %v, want %v", diff.NewCycles, want)
+	}
+}
@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// DominatorTree holds the immediate dominator of every function reachable from
+// an entry: the closest function that lies on every call path from the entry.
+type DominatorTree struct {
+	Entry    string              `json:"entry"`
+	IDom     map[string]string   `json:"idom"`
+	Children map[string][]string `json:"children"`
+	// Dominated counts the functions each function dominates, not counting itself.
+	Dominated map[string]int `json:"dominated"`
+}
+
+// BuildDominatorTree computes the dominator tree of the functions reachable
+// from entry with the iterative algorithm of Cooper, Harvey and Kennedy.
+func BuildDominatorTree(graph *CallGraph, entry string) (*DominatorTree, error) {
+	start, err := FindNode(graph, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	// Number the reachable functions in postorder.
+	postorder := make(map[string]int)
+	var order []*FunctionNode
+	visited := make(map[string]bool)
+	var visit func(*FunctionNode)
+	visit = func(node *FunctionNode) {
+		visited[node.Name] = true
+		for _, name := range sortedKeys(node.Calls) {
+			if !visited[name] {
+				visit(node.Calls[name])
+			}
+		}
+		postorder[node.Name] = len(order)
+		order = append(order, node)
+	}
+	visit(start)
+
+	idom := map[string]string{start.Name: start.Name}
+	intersect := func(a, b string) string {
+		for a != b {
+			for postorder[a] < postorder[b] {
+				a = idom[a]
+			}
+			for postorder[b] < postorder[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		// Walk in reverse postorder, skipping the entry.
+		for i := len(order) - 2; i >= 0; i-- {
+			node := order[i]
+			newIDom := ""
+			for _, pred := range sortedKeys(node.CalledBy) {
+				if _, ok := idom[pred]; !ok {
+					continue
+				}
+				if newIDom == "" {
+					newIDom = pred
+				} else {
+					newIDom = intersect(pred, newIDom)
+				}
+			}
+			if newIDom != "" && idom[node.Name] != newIDom {
+				idom[node.Name] = newIDom
+				changed = true
+			}
+		}
+	}
+
+	tree := &DominatorTree{
+		Entry:     start.Name,
+		IDom:      make(map[string]string),
+		Children:  make(map[string][]string),
+		Dominated: make(map[string]int),
+	}
+	for name, dom := range idom {
+		if name != start.Name {
+			tree.IDom[name] = dom
+			tree.Children[dom] = append(tree.Children[dom], name)
+		}
+	}
+	for _, children := range tree.Children {
+		sort.Strings(children)
+	}
+	// Children come before their dominator in postorder.
+	for _, node := range order {
+		if dom, ok := tree.IDom[node.Name]; ok {
+			tree.Dominated[dom] += tree.Dominated[node.Name] + 1
+		}
+	}
+	return tree, nil
+}
+
+// Dominators returns the functions on every call path from the entry to name,
+// starting at the entry and ending with the immediate dominator of name.
+func (t *DominatorTree) Dominators(name string) []string {
+	var chain []string
+	for dom, ok := t.IDom[name]; ok; dom, ok = t.IDom[dom] {
+		chain = append([]string{dom}, chain...)
+	}
+	return chain
+}
+
+// Chokepoints returns the functions other than the entry that dominate at least
+// min functions, the ones dominating the most first.
+func (t *DominatorTree) Chokepoints(min int) []string {
+	var chokepoints []string
+	for name, n := range t.Dominated {
+		if name != t.Entry && n >= min {
+			chokepoints = append(chokepoints, name)
+		}
+	}
+	sort.Slice(chokepoints, func(i, j int) bool {
+		a, b := chokepoints[i], chokepoints[j]
+		if t.Dominated[a] != t.Dominated[b] {
+			return t.Dominated[a] > t.Dominated[b]
+		}
+		return a < b
+	})
+	return chokepoints
+}
+
+// DOT renders the dominator tree, with chokepoints highlighted.
+func (t *DominatorTree) DOT(min int) []byte {
+	chokepoints := make(map[string]bool)
+	for _, name := range t.Chokepoints(min) {
+		chokepoints[name] = true
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph G {\n")
+	buf.WriteString("    rankdir=TB;\n")
+	buf.WriteString("    node [style=filled, fillcolor=lightgray, shape=rectangle];\n")
+	names := map[string]bool{t.Entry: true}
+	for name := range t.IDom {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		color := "lightgray"
+		if name == t.Entry {
+			color = "#AED6F1" // Light blue
+		} else if chokepoints[name] {
+			color = "#F5B7B1" // Light red
+		}
+		label := name
+		if n := t.Dominated[name]; n > 0 {
+			label = fmt.Sprintf("%s\n(dominates %d)", name, n)
+		}
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", fillcolor=\"%s\"];\n", sanitizeIdentifier(name), escapeStringForDOT(label), color))
+	}
+	for _, name := range sortedKeys(t.IDom) {
+		buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\";\n", sanitizeIdentifier(t.IDom[name]), sanitizeIdentifier(name)))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// DominatorsOptions configures a Dominators run.
+type DominatorsOptions struct {
+	Function     string // Only report the dominators of this function.
+	MinDominated int    // Functions a chokepoint must dominate.
+	Format       string // Output format: text, json or dot.
+	Output       string // Output file, stdout when empty.
+}
+
+// Dominators reports the dominator tree of the project from entry.
+func Dominators(project, entry string, opts DominatorsOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	tree, err := BuildDominatorTree(p.Graph, entry)
+	if err != nil {
+		return err
+	}
+
+	if opts.Function != "" {
+		node, err := FindNode(p.Graph, opts.Function)
+		if err != nil {
+			return err
+		}
+		if _, ok := tree.IDom[node.Name]; !ok {
+			return fmt.Errorf("%s is not reachable from %s", node.Name, tree.Entry)
+		}
+		chain := tree.Dominators(node.Name)
+		switch opts.Format {
+		case "", "text":
+			return writeOutput(opts.Output, []byte(fmt.Sprintf("Every path from %s to %s goes through: %s\n",
+				tree.Entry, node.Name, strings.Join(chain, " -> "))))
+		case "json":
+			return writeJSON(opts.Output, chain)
+		default:
+			return fmt.Errorf("unknown format: %s", opts.Format)
+		}
+	}
+
+	switch opts.Format {
+	case "", "text":
+		var buf strings.Builder
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "FUNCTION\tIMMEDIATE DOMINATOR\tDOMINATES")
+		for _, name := range sortedKeys(tree.IDom) {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", name, tree.IDom[name], tree.Dominated[name])
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		buf.WriteString("Chokepoints:\n")
+		for _, name := range tree.Chokepoints(opts.MinDominated) {
+			fmt.Fprintf(&buf, "  %s dominates %d functions\n", name, tree.Dominated[name])
+		}
+		return writeOutput(opts.Output, []byte(buf.String()))
+	case "json":
+		return writeJSON(opts.Output, tree)
+	case "dot":
+		return writeOutput(opts.Output, tree.DOT(opts.MinDominated))
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
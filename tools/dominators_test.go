@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestBuildDominatorTree tests the BuildDominatorTree function.
+func TestBuildDominatorTree(t *testing.T) {
+	graph := newTestGraph(
+		Edge{"main", "run"},
+		Edge{"run", "load"},
+		Edge{"run", "render"},
+		Edge{"load", "parse"},
+		Edge{"render", "parse"},
+		Edge{"parse", "decode"},
+		Edge{"main", "flags"},
+		Edge{"decode", "parse"},
+	)
+
+	tree, err := BuildDominatorTree(graph, "main")
+	if err != nil {
+		t.Fatalf("BuildDominatorTree() error = %v", err)
+	}
+
+	wantIDom := map[string]string{
+		"run":    "main",
+		"flags":  "main",
+		"load":   "run",
+		"render": "run",
+		"parse":  "run",
+		"decode": "parse",
+	}
+	if !reflect.DeepEqual(tree.IDom, wantIDom) {
+		t.Errorf("IDom = %v, want %v", tree.IDom, wantIDom)
+	}
+
+	wantDominated := map[string]int{"main": 6, "run": 4, "parse": 1}
+	if !reflect.DeepEqual(tree.Dominated, wantDominated) {
+		t.Errorf("Dominated = %v, want %v", tree.Dominated, wantDominated)
+	}
+
+	if got, want := tree.Dominators("decode"), []string{"main", "run", "parse"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dominators(decode) = %v, want %v", got, want)
+	}
+	if got, want := tree.Chokepoints(1), []string{"run", "parse"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Chokepoints(1) = %v, want %v", got, want)
+	}
+
+	if _, err := BuildDominatorTree(graph, "missing"); err == nil {
+		t.Error("BuildDominatorTree(missing) expected an error")
+	}
+}
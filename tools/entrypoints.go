@@ -0,0 +1,58 @@
+package tools
+
+import "sort"
+
+// FindEntrypoints returns the functions execution can start from: main and init
+// functions, and exported functions and methods without callers in the project,
+// which make up the API of a library. The result is sorted by name.
+func FindEntrypoints(p *Project) []*FunctionNode {
+	var entrypoints []*FunctionNode
+	for _, node := range p.Graph.Nodes {
+		if isEntrypoint(node) {
+			entrypoints = append(entrypoints, node)
+		}
+	}
+	sort.Slice(entrypoints, func(i, j int) bool { return entrypoints[i].Name < entrypoints[j].Name })
+	return entrypoints
+}
+
+func isEntrypoint(node *FunctionNode) bool {
+	fi := node.Info
+	if fi == nil {
+		return false
+	}
+	if fi.StructName == "" && (fi.Name == "init" || (fi.Name == "main" && fi.PkgName == "main")) {
+		return true
+	}
+	return len(node.CalledBy) == 0 && isUpperCase(fi.Name[0])
+}
+
+// reachableFrom returns the functions reachable from start, including start,
+// mapped to the function they were first reached from.
+func reachableFrom(start *FunctionNode) map[string]string {
+	parents := map[string]string{start.Name: ""}
+	queue := []*FunctionNode{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, name := range sortedKeys(node.Calls) {
+			if _, seen := parents[name]; !seen {
+				parents[name] = node.Name
+				queue = append(queue, node.Calls[name])
+			}
+		}
+	}
+	return parents
+}
+
+// pathTo rebuilds the shortest path to name from the parents returned by reachableFrom.
+func pathTo(parents map[string]string, name string) []string {
+	if _, ok := parents[name]; !ok {
+		return nil
+	}
+	var path []string
+	for ; name != ""; name = parents[name] {
+		path = append([]string{name}, path...)
+	}
+	return path
+}
@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// ErrorDefinition is a sentinel error variable or a custom error type.
+type ErrorDefinition struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"` // sentinel, type or external.
+	Message  string `json:"message,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Returned bool   `json:"returned"` // Whether any function can return it.
+}
+
+// ErrorSite is a place where a function creates, wraps, returns or compares an error.
+type ErrorSite struct {
+	Function string `json:"function"`
+	Kind     string `json:"kind"` // new, wrap, return, is, as or compare.
+	Error    string `json:"error,omitempty"`
+	Message  string `json:"message,omitempty"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// ErrorCatalog lists the errors of a project and how they propagate.
+type ErrorCatalog struct {
+	Definitions []ErrorDefinition `json:"definitions"`
+	Sites       []ErrorSite       `json:"sites"`
+	// Propagation maps every entrypoint to the sentinel errors that can reach it.
+	Propagation map[string][]string `json:"propagation"`
+}
+
+// BuildErrorCatalog collects the sentinel errors, custom error types and error
+// sites of the project, then walks the call graph to find which sentinels can
+// propagate to each entrypoint. Functions returning an error are assumed to
+// pass on the errors of everything they call.
+func BuildErrorCatalog(p *Project) (*ErrorCatalog, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+	catalog := &ErrorCatalog{Propagation: make(map[string][]string)}
+	definitions := make(map[string]*ErrorDefinition)
+
+	// Custom error types are the receivers of an Error() string method.
+	for _, fi := range p.Functions {
+		if fi.StructName != "" && fi.Name == "Error" && len(fi.Parameters) == 0 &&
+			len(fi.Returns) == 1 && fi.Returns[0].Type == "string" {
+			name := fi.PkgName + "." + strings.TrimPrefix(fi.StructName, "*")
+			definitions[name] = &ErrorDefinition{Name: name, Kind: "type", File: fi.RelativeFilePath, Line: fi.LineNumberStart}
+		}
+	}
+
+	// Sentinel errors are package level variables initialised with errors.New or fmt.Errorf.
+	for _, sf := range files {
+		for _, decl := range sf.File.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) {
+						break
+					}
+					call, ok := vs.Values[i].(*ast.CallExpr)
+					if !ok {
+						continue
+					}
+					if callee := sf.callName(call); callee != "errors.New" && callee != "fmt.Errorf" {
+						continue
+					}
+					qualified := sf.File.Name.Name + "." + name.Name
+					definitions[qualified] = &ErrorDefinition{
+						Name:    qualified,
+						Kind:    "sentinel",
+						Message: firstStringArg(call),
+						File:    sf.RelPath,
+						Line:    sf.line(name.Pos()),
+					}
+				}
+			}
+		}
+	}
+
+	// Walk the functions for error sites and the sentinels each one returns directly.
+	direct := make(map[string]map[string]bool)
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			caller := funcDeclFullName(fd)
+			site := func(pos token.Pos, kind, errName, message string) {
+				catalog.Sites = append(catalog.Sites, ErrorSite{
+					Function: caller, Kind: kind, Error: errName, Message: message,
+					File: sf.RelPath, Line: sf.line(pos),
+				})
+			}
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.CallExpr:
+					switch sf.callName(n) {
+					case "errors.New":
+						site(n.Pos(), "new", "", firstStringArg(n))
+					case "fmt.Errorf":
+						if msg := firstStringArg(n); strings.Contains(msg, "%w") {
+							site(n.Pos(), "wrap", sentinelIn(sf, n.Args[1:], definitions), msg)
+						} else {
+							site(n.Pos(), "new", "", msg)
+						}
+					case "errors.Is":
+						if len(n.Args) == 2 {
+							site(n.Pos(), "is", errorRef(sf, n.Args[1], definitions), "")
+						}
+					case "errors.As":
+						if len(n.Args) == 2 {
+							site(n.Pos(), "as", exprToString(n.Args[1]), "")
+						}
+					}
+				case *ast.BinaryExpr:
+					if n.Op == token.EQL || n.Op == token.NEQ {
+						for _, operand := range []ast.Expr{n.X, n.Y} {
+							if ref := errorRef(sf, operand, definitions); ref != "" {
+								site(n.Pos(), "compare", ref, "")
+							}
+						}
+					}
+				case *ast.ReturnStmt:
+					if ref := sentinelIn(sf, n.Results, definitions); ref != "" {
+						site(n.Pos(), "return", ref, "")
+						if direct[caller] == nil {
+							direct[caller] = make(map[string]bool)
+						}
+						direct[caller][ref] = true
+					}
+				case *ast.FuncLit:
+					// Returns inside closures do not return from the function.
+					return false
+				}
+				return true
+			})
+		}
+	}
+
+	// Propagate the sentinels from callees to error-returning callers until nothing changes.
+	propagated := make(map[string]map[string]bool)
+	for name, errs := range direct {
+		propagated[name] = make(map[string]bool)
+		for e := range errs {
+			propagated[name][e] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, node := range p.Graph.Nodes {
+			if node.Info == nil || errorIndexes(node.Info) == nil {
+				continue
+			}
+			for callee := range node.Calls {
+				for e := range propagated[callee] {
+					if propagated[name] == nil {
+						propagated[name] = make(map[string]bool)
+					}
+					if !propagated[name][e] {
+						propagated[name][e] = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	// Entrypoints such as main often return nothing but still receive the errors
+	// of the functions they call.
+	for _, entry := range FindEntrypoints(p) {
+		errs := make(map[string]bool)
+		for e := range propagated[entry.Name] {
+			errs[e] = true
+		}
+		for callee := range entry.Calls {
+			for e := range propagated[callee] {
+				errs[e] = true
+			}
+		}
+		if len(errs) > 0 {
+			catalog.Propagation[entry.Name] = sortedKeys(errs)
+		}
+	}
+	for _, errs := range direct {
+		for e := range errs {
+			if d, ok := definitions[e]; ok {
+				d.Returned = true
+			} else {
+				definitions[e] = &ErrorDefinition{Name: e, Kind: "external", Returned: true}
+			}
+		}
+	}
+	for _, name := range sortedKeys(definitions) {
+		catalog.Definitions = append(catalog.Definitions, *definitions[name])
+	}
+	return catalog, nil
+}
+
+// sentinelIn returns the first sentinel or custom error type referenced by the expressions.
+func sentinelIn(sf *sourceFile, exprs []ast.Expr, definitions map[string]*ErrorDefinition) string {
+	for _, expr := range exprs {
+		var found string
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if found != "" {
+				return false
+			}
+			if e, ok := n.(ast.Expr); ok {
+				if ref := errorRef(sf, e, definitions); ref != "" {
+					found = ref
+					return false
+				}
+			}
+			if lit, ok := n.(*ast.CompositeLit); ok {
+				if name := sf.File.Name.Name + "." + exprToString(lit.Type); definitions[name] != nil && definitions[name].Kind == "type" {
+					found = name
+					return false
+				}
+			}
+			return true
+		})
+		if found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
+// errorRef returns the qualified name of the sentinel error an expression refers
+// to: a sentinel of the project, or an ErrX or EOF variable of another package.
+func errorRef(sf *sourceFile, expr ast.Expr, definitions map[string]*ErrorDefinition) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		name := sf.File.Name.Name + "." + e.Name
+		if d, ok := definitions[name]; ok && d.Kind == "sentinel" {
+			return name
+		}
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok {
+			return ""
+		}
+		if _, isPkg := sf.Imports[x.Name]; !isPkg {
+			return ""
+		}
+		name := x.Name + "." + e.Sel.Name
+		if _, ok := definitions[name]; ok || isSentinelName(e.Sel.Name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// isSentinelName reports whether a variable name follows the ErrX convention of
+// sentinel errors, or is io.EOF.
+func isSentinelName(name string) bool {
+	return name == "EOF" || (len(name) > 3 && strings.HasPrefix(name, "Err") && isUpperCase(name[3]))
+}
+
+// firstStringArg returns the first argument of a call if it is a string literal.
+func firstStringArg(call *ast.CallExpr) string {
+	if len(call.Args) == 0 {
+		return ""
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return lit.Value
+	}
+	return s
+}
+
+// ErrorsOptions configures an Errors run.
+type ErrorsOptions struct {
+	Format string // Output format: text or json.
+	Output string // Output file, stdout when empty.
+}
+
+// Errors reports the error catalog of the project.
+func Errors(project string, opts ErrorsOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	catalog, err := BuildErrorCatalog(p)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "text":
+		return writeOutput(opts.Output, []byte(catalog.String()))
+	case "json":
+		return writeJSON(opts.Output, catalog)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
+
+// String formats the catalog as a text report.
+func (c *ErrorCatalog) String() string {
+	var buf strings.Builder
+	buf.WriteString("Errors:\n")
+	for _, d := range c.Definitions {
+		fmt.Fprintf(&buf, "  %s (%s)", d.Name, d.Kind)
+		if d.Message != "" {
+			fmt.Fprintf(&buf, " %q", d.Message)
+		}
+		if d.File != "" {
+			fmt.Fprintf(&buf, " %s:%d", d.File, d.Line)
+		}
+		if !d.Returned {
+			buf.WriteString(" [never returned]")
+		}
+		buf.WriteString("\n")
+	}
+
+	sites := make(map[string][]ErrorSite)
+	for _, s := range c.Sites {
+		sites[s.Function] = append(sites[s.Function], s)
+	}
+	buf.WriteString("Error sites:\n")
+	for _, fn := range sortedKeys(sites) {
+		fmt.Fprintf(&buf, "  %s\n", fn)
+		for _, s := range sites[fn] {
+			detail := s.Error
+			if s.Message != "" {
+				detail = strings.TrimSpace(detail + " " + strconv.Quote(s.Message))
+			}
+			fmt.Fprintf(&buf, "    %-7s %s %s:%d\n", s.Kind, detail, s.File, s.Line)
+		}
+	}
+
+	buf.WriteString("Propagation to entrypoints:\n")
+	for _, entry := range sortedKeys(c.Propagation) {
+		fmt.Fprintf(&buf, "  %s: %s\n", entry, strings.Join(c.Propagation[entry], ", "))
+	}
+	return buf.String()
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestBuildErrorCatalog tests the BuildErrorCatalog function.
+func TestBuildErrorCatalog(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("not found")
+
+var ErrUnused = errors.New("unused")
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func run() error {
+	if _, err := get("key"); err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
+	return nil
+}
+
+func get(key string) (string, error) {
+	return "", ErrNotFound
+}
+`,
+	})
+	catalog, err := BuildErrorCatalog(p)
+	if err != nil {
+		t.Fatalf("BuildErrorCatalog() error = %v", err)
+	}
+
+	want := map[string][]string{"main": {"main.ErrNotFound"}}
+	if !reflect.DeepEqual(catalog.Propagation, want) {
+		t.Errorf("Propagation = %v, want %v", catalog.Propagation, want)
+	}
+
+	tests := []struct {
+		name     string
+		kind     string
+		returned bool
+	}{
+		{name: "main.ErrNotFound", kind: "sentinel", returned: true},
+		{name: "main.ErrUnused", kind: "sentinel", returned: false},
+	}
+	definitions := make(map[string]ErrorDefinition)
+	for _, d := range catalog.Definitions {
+		definitions[d.Name] = d
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := definitions[tt.name]
+			if !ok {
+				t.Fatalf("%s is not in the catalog", tt.name)
+			}
+			if d.Kind != tt.kind || d.Returned != tt.returned {
+				t.Errorf("%s = %s returned %v, want %s returned %v", tt.name, d.Kind, d.Returned, tt.kind, tt.returned)
+			}
+		})
+	}
+
+	sites := make(map[string]bool)
+	for _, s := range catalog.Sites {
+		sites[s.Function+" "+s.Kind+" "+s.Error] = true
+	}
+	for _, site := range []string{"run wrap ", "get return main.ErrNotFound"} {
+		if !sites[site] {
+			t.Errorf("missing error site %q in %v", site, catalog.Sites)
+		}
+	}
+}
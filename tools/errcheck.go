@@ -0,0 +1,239 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// DefaultErrorAllowlist lists functions whose error result is safe to ignore.
+var DefaultErrorAllowlist = []string{
+	"fmt.Print*",
+	"fmt.Fprint*",
+}
+
+// stdlibErrorFuncs lists common functions outside the project whose last
+// result is an error.
+var stdlibErrorFuncs = map[string]bool{
+	"fmt.Print": true, "fmt.Println": true, "fmt.Printf": true,
+	"fmt.Fprint": true, "fmt.Fprintln": true, "fmt.Fprintf": true,
+	"fmt.Sscanf": true, "fmt.Sscan": true,
+	"os.Remove": true, "os.RemoveAll": true, "os.Rename": true,
+	"os.Mkdir": true, "os.MkdirAll": true, "os.WriteFile": true,
+	"os.Chdir": true, "os.Chmod": true, "os.Chown": true,
+	"os.Setenv": true, "os.Unsetenv": true, "os.Symlink": true,
+	"json.Unmarshal": true, "xml.Unmarshal": true,
+	"io.Copy": true, "io.CopyN": true, "io.ReadFull": true, "io.WriteString": true,
+	"http.ListenAndServe": true, "http.ListenAndServeTLS": true,
+}
+
+// errorMethods lists method names that return an error on the common standard
+// library types, used for calls whose receiver type cannot be resolved.
+var errorMethods = map[string]bool{
+	"Close": true, "Flush": true, "Sync": true,
+	"Commit": true, "Rollback": true, "Shutdown": true,
+}
+
+// IgnoredError is a call site that discards the error result of its callee.
+type IgnoredError struct {
+	Caller     string `json:"caller"`
+	Callee     string `json:"callee"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Kind       string `json:"kind"`       // statement, blank, defer or go.
+	Centrality int    `json:"centrality"` // Number of distinct callers of the callee.
+}
+
+// FindIgnoredErrors reports every call site discarding an error result, most
+// central callees first. Callees matching an allowlist pattern are skipped.
+func FindIgnoredErrors(p *Project, allowlist []string) ([]IgnoredError, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []IgnoredError
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			caller := funcDeclFullName(fd)
+			report := func(call *ast.CallExpr, kind string) {
+				callee := sf.callName(call)
+				if isAllowed(callee, allowlist) {
+					return
+				}
+				centrality := 0
+				if node, ok := p.Graph.Nodes[callee]; ok {
+					centrality = len(node.CalledBy)
+				}
+				results = append(results, IgnoredError{
+					Caller:     caller,
+					Callee:     callee,
+					File:       sf.RelPath,
+					Line:       sf.line(call.Pos()),
+					Kind:       kind,
+					Centrality: centrality,
+				})
+			}
+
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				switch s := n.(type) {
+				case *ast.ExprStmt:
+					if call, ok := ast.Unparen(s.X).(*ast.CallExpr); ok && p.errorResults(sf, call) != nil {
+						report(call, "statement")
+					}
+				case *ast.DeferStmt:
+					if p.errorResults(sf, s.Call) != nil {
+						report(s.Call, "defer")
+					}
+				case *ast.GoStmt:
+					if p.errorResults(sf, s.Call) != nil {
+						report(s.Call, "go")
+					}
+				case *ast.AssignStmt:
+					if len(s.Rhs) != 1 {
+						return true
+					}
+					call, ok := ast.Unparen(s.Rhs[0]).(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					for _, i := range p.errorResults(sf, call) {
+						// Without the callee's result count, the error is assumed to be last.
+						if i < 0 {
+							i = len(s.Lhs) - 1
+						}
+						if i < len(s.Lhs) && isBlank(s.Lhs[i]) {
+							report(call, "blank")
+							break
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Centrality != results[j].Centrality {
+			return results[i].Centrality > results[j].Centrality
+		}
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		return results[i].Line < results[j].Line
+	})
+	return results, nil
+}
+
+// errorResults returns the indexes of the callee's error results, -1 standing
+// for the last result of a callee whose signature is unknown. It returns nil
+// when the callee is not known to return an error.
+func (p *Project) errorResults(sf *sourceFile, call *ast.CallExpr) []int {
+	if stdlibErrorFuncs[sf.callName(call)] {
+		return []int{-1}
+	}
+	// Only trust a method name when every method sharing it agrees.
+	if candidates := p.calleeInfos(sf, call); len(candidates) > 0 {
+		indexes := errorIndexes(candidates[0])
+		for _, fi := range candidates[1:] {
+			if fmt.Sprint(errorIndexes(fi)) != fmt.Sprint(indexes) {
+				return nil
+			}
+		}
+		return indexes
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok && errorMethods[sel.Sel.Name] {
+		if x, ok := sel.X.(*ast.Ident); ok {
+			if _, isPkg := sf.Imports[x.Name]; isPkg {
+				return nil
+			}
+		}
+		return []int{-1}
+	}
+	return nil
+}
+
+// errorIndexes returns the positions of the error results of a function.
+func errorIndexes(fi *FunctionInfo) []int {
+	var indexes []int
+	for i, r := range fi.Returns {
+		if r.Type == "error" {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+// isAllowed reports whether name matches one of the glob patterns.
+func isAllowed(name string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrCheckOptions configures an ErrCheck run.
+type ErrCheckOptions struct {
+	Allow  []string // Additional allowlist patterns.
+	Format string   // Output format: text or json.
+	Output string   // Output file, stdout when empty.
+}
+
+// ErrCheck reports the call sites of the project that ignore error results.
+func ErrCheck(project string, opts ErrCheckOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	results, err := FindIgnoredErrors(p, append(append([]string{}, DefaultErrorAllowlist...), opts.Allow...))
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case "", "text":
+		var buf strings.Builder
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CALLEE\tCALLERS\tKIND\tCALLER\tLOCATION")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s:%d\n", r.Callee, r.Centrality, r.Kind, r.Caller, r.File, r.Line)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "%d ignored error results\n", len(results))
+		return writeOutput(opts.Output, []byte(buf.String()))
+	case "json":
+		return writeJSON(opts.Output, results)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
+
+// checkIgnoredErrors reports ignored error results as rule violations.
+func checkIgnoredErrors(p *Project) ([]Violation, error) {
+	results, err := FindIgnoredErrors(p, DefaultErrorAllowlist)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for _, r := range results {
+		violations = append(violations, Violation{
+			Function: r.Caller,
+			File:     r.File,
+			Line:     r.Line,
+			Message:  fmt.Sprintf("error result of %s is ignored (%s)", r.Callee, r.Kind),
+		})
+	}
+	return violations, nil
+}
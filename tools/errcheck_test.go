@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindIgnoredErrors tests the FindIgnoredErrors function.
+func TestFindIgnoredErrors(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"sample.go": `package sample
+
+import (
+	"fmt"
+	"os"
+)
+
+func save() error {
+	return nil
+}
+
+func load() (string, error) {
+	return "", nil
+}
+
+func run(f *os.File) {
+	save()
+	_, _ = load()
+	defer f.Close()
+	os.Remove("tmp")
+	fmt.Println("done")
+	if err := save(); err != nil {
+		return
+	}
+	v, err := load()
+	_, _ = v, err
+}
+`,
+	})
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		want      []IgnoredError
+	}{
+		{
+			name:      "default allowlist",
+			allowlist: DefaultErrorAllowlist,
+			want: []IgnoredError{
+				{Caller: "run", Callee: "save", File: "sample.go", Line: 17, Kind: "statement", Centrality: 1},
+				{Caller: "run", Callee: "load", File: "sample.go", Line: 18, Kind: "blank", Centrality: 1},
+				{Caller: "run", Callee: "f.Close", File: "sample.go", Line: 19, Kind: "defer", Centrality: 1},
+				{Caller: "run", Callee: "os.Remove", File: "sample.go", Line: 20, Kind: "statement", Centrality: 1},
+			},
+		},
+		{
+			name:      "allowed callees",
+			allowlist: []string{"fmt.*", "os.*", "*.Close", "load"},
+			want: []IgnoredError{
+				{Caller: "run", Callee: "save", File: "sample.go", Line: 17, Kind: "statement", Centrality: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindIgnoredErrors(p, tt.allowlist)
+			if err != nil {
+				t.Fatalf("FindIgnoredErrors() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindIgnoredErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
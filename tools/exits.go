@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// terminatingCalls lists the calls that end the process or the current goroutine.
+var terminatingCalls = map[string]bool{
+	"panic":          true,
+	"os.Exit":        true,
+	"log.Fatal":      true,
+	"log.Fatalf":     true,
+	"log.Fatalln":    true,
+	"runtime.Goexit": true,
+}
+
+// processExitCalls are the terminating calls that always end the process.
+var processExitCalls = map[string]bool{
+	"os.Exit":     true,
+	"log.Fatal":   true,
+	"log.Fatalf":  true,
+	"log.Fatalln": true,
+}
+
+// ExitSite is a call to a terminating function and how entrypoints reach it.
+type ExitSite struct {
+	Function string `json:"function"`
+	Package  string `json:"package"`
+	Callee   string `json:"callee"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Paths maps every entrypoint that can reach the call to the shortest call path.
+	Paths map[string][]string `json:"paths"`
+}
+
+// FindExitSites locates every call to panic, os.Exit, log.Fatal* and
+// runtime.Goexit and computes the shortest path to it from each entrypoint.
+func FindExitSites(p *Project) ([]ExitSite, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var sites []ExitSite
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			caller := funcDeclFullName(fd)
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				if callee := sf.callName(call); terminatingCalls[callee] {
+					sites = append(sites, ExitSite{
+						Function: caller,
+						Package:  sf.File.Name.Name,
+						Callee:   callee,
+						File:     sf.RelPath,
+						Line:     sf.line(call.Pos()),
+						Paths:    make(map[string][]string),
+					})
+				}
+				return true
+			})
+		}
+	}
+
+	for _, entry := range FindEntrypoints(p) {
+		parents := reachableFrom(entry)
+		for i := range sites {
+			if path := pathTo(parents, sites[i].Function); path != nil {
+				sites[i].Paths[entry.Name] = append(path, sites[i].Callee)
+			}
+		}
+	}
+	return sites, nil
+}
+
+// ExitsOptions configures an Exits run.
+type ExitsOptions struct {
+	Format string // Output format: text or json.
+	Output string // Output file, stdout when empty.
+}
+
+// Exits reports the terminating calls of the project and the entrypoints reaching them.
+func Exits(project string, opts ExitsOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	sites, err := FindExitSites(p)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "text":
+		var buf strings.Builder
+		for _, s := range sites {
+			fmt.Fprintf(&buf, "%s in %s at %s:%d\n", s.Callee, s.Function, s.File, s.Line)
+			if len(s.Paths) == 0 {
+				buf.WriteString("  not reachable from any entrypoint\n")
+			}
+			for _, entry := range sortedKeys(s.Paths) {
+				fmt.Fprintf(&buf, "  %s\n", strings.Join(s.Paths[entry], " -> "))
+			}
+		}
+		fmt.Fprintf(&buf, "%d terminating calls\n", len(sites))
+		return writeOutput(opts.Output, []byte(buf.String()))
+	case "json":
+		return writeJSON(opts.Output, sites)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
+
+// checkLibraryExits reports process-terminating calls outside main packages.
+func checkLibraryExits(p *Project) ([]Violation, error) {
+	sites, err := FindExitSites(p)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for _, s := range sites {
+		if s.Package == "main" || !processExitCalls[s.Callee] {
+			continue
+		}
+		violations = append(violations, Violation{
+			Function: s.Function,
+			File:     s.File,
+			Line:     s.Line,
+			Message:  fmt.Sprintf("library package %s terminates the process with %s", s.Package, s.Callee),
+		})
+	}
+	return violations, nil
+}
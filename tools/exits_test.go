@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindExitSites tests the FindExitSites function.
+func TestFindExitSites(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	check()
+	return nil
+}
+
+func check() {
+	os.Exit(2)
+}
+
+func unreachable() {
+	panic("never")
+}
+`,
+	})
+	sites, err := FindExitSites(p)
+	if err != nil {
+		t.Fatalf("FindExitSites() error = %v", err)
+	}
+
+	tests := []struct {
+		function string
+		callee   string
+		line     int
+		paths    map[string][]string
+	}{
+		{function: "main", callee: "log.Fatal", line: 10, paths: map[string][]string{"main": {"main", "log.Fatal"}}},
+		{function: "check", callee: "os.Exit", line: 20, paths: map[string][]string{"main": {"main", "run", "check", "os.Exit"}}},
+		{function: "unreachable", callee: "panic", line: 24, paths: map[string][]string{}},
+	}
+	if len(sites) != len(tests) {
+		t.Fatalf("FindExitSites() found %d sites, want %d: %+v", len(sites), len(tests), sites)
+	}
+	for i, tt := range tests {
+		t.Run(tt.callee, func(t *testing.T) {
+			s := sites[i]
+			if s.Function != tt.function || s.Callee != tt.callee || s.Line != tt.line || s.Package != "main" {
+				t.Errorf("site = %s calls %s on line %d in %s, want %s calls %s on line %d in main",
+					s.Function, s.Callee, s.Line, s.Package, tt.function, tt.callee, tt.line)
+			}
+			if !reflect.DeepEqual(s.Paths, tt.paths) {
+				t.Errorf("Paths = %v, want %v", s.Paths, tt.paths)
+			}
+		})
+	}
+}
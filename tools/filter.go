@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FilterOptions selects the part of a call graph to render.
+type FilterOptions struct {
+	Focus     string // Function to centre the graph on, the whole graph when empty.
+	Depth     int    // Maximum distance from Focus, unlimited when 0.
+	Direction string // callers, callees or both.
+	Include   string // Only keep functions matching this regular expression.
+	Exclude   string // Drop functions matching this regular expression and everything only reachable through them.
+}
+
+// IsZero reports whether the options leave the graph unchanged.
+func (o FilterOptions) IsZero() bool {
+	return o.Focus == "" && o.Include == "" && o.Exclude == ""
+}
+
+// FilterGraph returns a pruned copy of the graph. Every kept function whose
+// callers or callees were cut gets an elided placeholder node standing in for
+// them, so the reader knows the graph continues.
+func FilterGraph(graph *CallGraph, opts FilterOptions) (*CallGraph, error) {
+	var include, exclude *regexp.Regexp
+	var err error
+	if opts.Include != "" {
+		if include, err = regexp.Compile(opts.Include); err != nil {
+			return nil, fmt.Errorf("invalid include pattern: %w", err)
+		}
+	}
+	if opts.Exclude != "" {
+		if exclude, err = regexp.Compile(opts.Exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+		}
+	}
+
+	callers, callees := true, true
+	switch opts.Direction {
+	case "", "both":
+	case "callers":
+		callees = false
+	case "callees":
+		callers = false
+	default:
+		return nil, fmt.Errorf("unknown direction: %s", opts.Direction)
+	}
+
+	excluded := func(name string) bool {
+		return exclude != nil && exclude.MatchString(name)
+	}
+
+	// Collect the reachable region, not walking through excluded functions.
+	reached := make(map[string]bool)
+	var focusName string
+	if opts.Focus == "" {
+		// Walk the callees from the functions nothing calls, and from those
+		// only called in cycles nothing calls, so that the functions only
+		// reachable through excluded ones are dropped with them.
+		var seeds []string
+		for _, name := range sortedKeys(graph.Nodes) {
+			if len(graph.Nodes[name].CalledBy) == 0 {
+				seeds = append(seeds, name)
+			}
+		}
+		callable := walkCallees(graph, seeds, func(string) bool { return false })
+		for _, name := range sortedKeys(graph.Nodes) {
+			if !callable[name] {
+				seeds = append(seeds, name)
+				for callee := range walkCallees(graph, []string{name}, func(string) bool { return false }) {
+					callable[callee] = true
+				}
+			}
+		}
+		var kept []string
+		for _, name := range seeds {
+			if !excluded(name) {
+				kept = append(kept, name)
+			}
+		}
+		reached = walkCallees(graph, kept, excluded)
+	} else {
+		focus, err := FindNode(graph, opts.Focus)
+		if err != nil {
+			return nil, err
+		}
+		focusName = focus.Name
+		reached[focusName] = true
+		frontier := []*FunctionNode{focus}
+		for depth := 0; len(frontier) > 0 && (opts.Depth <= 0 || depth < opts.Depth); depth++ {
+			var next []*FunctionNode
+			visit := func(neighbours map[string]*FunctionNode) {
+				for name, n := range neighbours {
+					if !reached[name] && !excluded(name) {
+						reached[name] = true
+						next = append(next, n)
+					}
+				}
+			}
+			for _, node := range frontier {
+				if callees {
+					visit(node.Calls)
+				}
+				if callers {
+					visit(node.CalledBy)
+				}
+			}
+			frontier = next
+		}
+	}
+
+	kept := make(map[string]bool)
+	for name := range reached {
+		if include == nil || include.MatchString(name) || name == focusName {
+			kept[name] = true
+		}
+	}
+
+	filtered := &CallGraph{Nodes: make(map[string]*FunctionNode)}
+	for name := range kept {
+		orig := graph.Nodes[name]
+		filtered.Nodes[name] = &FunctionNode{
+			Name:      name,
+			Calls:     make(map[string]*FunctionNode),
+			CalledBy:  make(map[string]*FunctionNode),
+			Info:      orig.Info,
+			Sites:     orig.Sites,
+			CallSites: orig.CallSites,
+		}
+	}
+	for name := range kept {
+		node := filtered.Nodes[name]
+		for callee := range graph.Nodes[name].Calls {
+			if kept[callee] {
+				node.Calls[callee] = filtered.Nodes[callee]
+				filtered.Nodes[callee].CalledBy[name] = node
+			}
+		}
+		if callees {
+			if cut := countCut(graph.Nodes[name], kept, func(n *FunctionNode) map[string]*FunctionNode { return n.Calls }); cut > 0 {
+				elided := addElidedNode(filtered, name+" callees", cut)
+				node.Calls[elided.Name] = elided
+				elided.CalledBy[name] = node
+			}
+		}
+		if callers {
+			if cut := countCut(graph.Nodes[name], kept, func(n *FunctionNode) map[string]*FunctionNode { return n.CalledBy }); cut > 0 {
+				elided := addElidedNode(filtered, name+" callers", cut)
+				elided.Calls[name] = node
+				node.CalledBy[elided.Name] = elided
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// walkCallees returns the functions reachable from start through calls,
+// not walking into the functions skip reports.
+func walkCallees(graph *CallGraph, start []string, skip func(name string) bool) map[string]bool {
+	reached := make(map[string]bool)
+	queue := append([]string{}, start...)
+	for _, name := range start {
+		reached[name] = true
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for callee := range graph.Nodes[name].Calls {
+			if !reached[callee] && !skip(callee) {
+				reached[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return reached
+}
+
+// countCut returns the number of functions pruned behind node in one
+// direction: its neighbours that were not kept and every function reachable
+// from them without passing through a kept one.
+func countCut(node *FunctionNode, kept map[string]bool, next func(*FunctionNode) map[string]*FunctionNode) int {
+	cut := make(map[string]bool)
+	queue := []*FunctionNode{node}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for name, neighbour := range next(n) {
+			if !kept[name] && !cut[name] {
+				cut[name] = true
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+	return len(cut)
+}
+
+// addElidedNode adds a placeholder node standing in for count cut functions.
+func addElidedNode(graph *CallGraph, key string, count int) *FunctionNode {
+	node := &FunctionNode{
+		Name:     "… " + key,
+		Calls:    make(map[string]*FunctionNode),
+		CalledBy: make(map[string]*FunctionNode),
+		Elided:   count,
+	}
+	graph.Nodes[node.Name] = node
+	return node
+}
+
+// FindNode looks up a function by its full name, falling back to a unique
+// function whose name ends in ".name".
+func FindNode(graph *CallGraph, name string) (*FunctionNode, error) {
+	if node, ok := graph.Nodes[name]; ok {
+		return node, nil
+	}
+	var matches []*FunctionNode
+	for full, node := range graph.Nodes {
+		if strings.HasSuffix(full, "."+name) {
+			matches = append(matches, node)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("function %s not found", name)
+	case 1:
+		return matches[0], nil
+	default:
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.Name)
+		}
+		return nil, fmt.Errorf("function %s is ambiguous: %s", name, strings.Join(names, ", "))
+	}
+}
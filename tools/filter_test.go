@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestFilterGraph tests the FilterGraph function.
+func TestFilterGraph(t *testing.T) {
+	graph := newTestGraph(
+		Edge{"main", "run"},
+		Edge{"run", "load"},
+		Edge{"run", "render"},
+		Edge{"load", "parse"},
+		Edge{"render", "fmt.Println"},
+		Edge{"poll", "tick"},
+		Edge{"tick", "poll"},
+	)
+
+	tests := []struct {
+		name      string
+		opts      FilterOptions
+		wantNodes []string
+		wantCut   map[string]int
+	}{
+		{
+			name:      "Callees within depth",
+			opts:      FilterOptions{Focus: "run", Depth: 1, Direction: "callees"},
+			wantNodes: []string{"load", "render", "run"},
+			wantCut:   map[string]int{"… load callees": 1, "… render callees": 1},
+		},
+		{
+			name:      "Callers",
+			opts:      FilterOptions{Focus: "parse", Direction: "callers"},
+			wantNodes: []string{"load", "main", "parse", "run"},
+		},
+		{
+			name:      "Exclude prunes the region beneath",
+			opts:      FilterOptions{Focus: "main", Direction: "callees", Exclude: "^render$"},
+			wantNodes: []string{"load", "main", "parse", "run"},
+			wantCut:   map[string]int{"… run callees": 2},
+		},
+		{
+			name:      "Exclude without focus prunes the region beneath",
+			opts:      FilterOptions{Exclude: "^render$"},
+			wantNodes: []string{"load", "main", "parse", "poll", "run", "tick"},
+			wantCut:   map[string]int{"… run callees": 2},
+		},
+		{
+			name:      "Exclude without focus keeps the other regions",
+			opts:      FilterOptions{Exclude: "^load$"},
+			wantNodes: []string{"fmt.Println", "main", "poll", "render", "run", "tick"},
+			wantCut:   map[string]int{"… run callees": 2},
+		},
+		{
+			name:      "Exclude without focus keeps cycles nothing calls",
+			opts:      FilterOptions{Exclude: "^tick$"},
+			wantNodes: []string{"fmt.Println", "load", "main", "parse", "poll", "render", "run"},
+			wantCut:   map[string]int{"… poll callees": 1, "… poll callers": 1},
+		},
+		{
+			name:      "Include",
+			opts:      FilterOptions{Include: "^(main|run)$"},
+			wantNodes: []string{"main", "run"},
+			wantCut:   map[string]int{"… run callees": 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := FilterGraph(graph, tt.opts)
+			if err != nil {
+				t.Fatalf("FilterGraph() error = %v", err)
+			}
+			var nodes []string
+			cut := make(map[string]int)
+			for name, node := range filtered.Nodes {
+				if node.Elided > 0 {
+					cut[name] = node.Elided
+				} else {
+					nodes = append(nodes, name)
+				}
+			}
+			sort.Strings(nodes)
+			if !reflect.DeepEqual(nodes, tt.wantNodes) {
+				t.Errorf("FilterGraph() nodes = %v, want %v", nodes, tt.wantNodes)
+			}
+			if tt.wantCut == nil {
+				tt.wantCut = map[string]int{}
+			}
+			if !reflect.DeepEqual(cut, tt.wantCut) {
+				t.Errorf("FilterGraph() placeholders = %v, want %v", cut, tt.wantCut)
+			}
+		})
+	}
+}
@@ -110,7 +110,7 @@ func GetFunctionCalls(functionCode string, fi FunctionInfo, projectRoot string)
 			return false
 		}
 		if callExpr, ok := n.(*ast.CallExpr); ok {
-			callInfo := extractCallInfo(callExpr, fset, projectRoot, fi.RelativeFilePath, imports)
+			callInfo := extractCallInfo(callExpr, fset, fi.RelativeFilePath, imports)
 			calls = append(calls, callInfo)
 			// Do not traverse into this callExpr's arguments, as extractCallInfo already handles that.
 			return false
@@ -122,7 +122,7 @@ func GetFunctionCalls(functionCode string, fi FunctionInfo, projectRoot string)
 }
 
 // extractCallInfo extracts information from a CallExpr and returns a FunctionCallInfo.
-func extractCallInfo(callExpr *ast.CallExpr, fset *token.FileSet, projectRoot, filePath string, imports map[string]struct{}) FunctionCallInfo {
+func extractCallInfo(callExpr *ast.CallExpr, fset *token.FileSet, filePath string, imports map[string]struct{}) FunctionCallInfo {
 	// Extract the function being called.
 	functionName, packageName, receiverName := getFunctionName(callExpr.Fun, imports)
 
@@ -139,7 +139,7 @@ func extractCallInfo(callExpr *ast.CallExpr, fset *token.FileSet, projectRoot, f
 	// Recursively get function calls within arguments.
 	var nestedCalls []FunctionCallInfo
 	for _, arg := range callExpr.Args {
-		nestedCalls = append(nestedCalls, extractNestedCalls(arg, fset, projectRoot, filePath, imports)...)
+		nestedCalls = append(nestedCalls, extractNestedCalls(arg, fset, filePath, imports)...)
 	}
 
 	// Get full expression.
@@ -153,7 +153,7 @@ func extractCallInfo(callExpr *ast.CallExpr, fset *token.FileSet, projectRoot, f
 		Calls:     nestedCalls,
 		FullExpr:  fullExpr,
 		Arguments: argExprs,
-		FilePath:  filepath.Join(projectRoot, filePath),
+		FilePath:  filepath.ToSlash(filePath),
 	}
 }
 
@@ -192,14 +192,14 @@ func getFunctionName(fun ast.Expr, imports map[string]struct{}) (functionName, p
 }
 
 // extractNestedCalls recursively extracts function calls within an expression.
-func extractNestedCalls(expr ast.Expr, fset *token.FileSet, projectRoot, filePath string, imports map[string]struct{}) []FunctionCallInfo {
+func extractNestedCalls(expr ast.Expr, fset *token.FileSet, filePath string, imports map[string]struct{}) []FunctionCallInfo {
 	var calls []FunctionCallInfo
 	ast.Inspect(expr, func(n ast.Node) bool {
 		if n == nil {
 			return false
 		}
 		if callExpr, ok := n.(*ast.CallExpr); ok {
-			callInfo := extractCallInfo(callExpr, fset, projectRoot, filePath, imports)
+			callInfo := extractCallInfo(callExpr, fset, filePath, imports)
 			calls = append(calls, callInfo)
 			// Do not traverse into this callExpr's arguments, as extractCallInfo already handles that.
 			return false
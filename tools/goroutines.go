@@ -0,0 +1,228 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// GoroutineSpawn is a go statement and everything the goroutine it starts can call.
+type GoroutineSpawn struct {
+	ID      string   `json:"id"`
+	Spawner string   `json:"spawner"` // Function containing the go statement.
+	Target  string   `json:"target"`  // Launched function, or the closure name for go func() { ... }().
+	Closure bool     `json:"closure"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Calls   []string `json:"calls"` // Functions the goroutine transitively calls.
+	Edges   []Edge   `json:"edges"` // Calls between the goroutine and the functions it reaches.
+}
+
+// GoroutineReport lists the goroutines of a project and the entrypoints that start them.
+type GoroutineReport struct {
+	Spawns []GoroutineSpawn `json:"spawns"`
+	// Entrypoints maps every entrypoint to the IDs of the spawns it can reach.
+	Entrypoints map[string][]string `json:"entrypoints"`
+}
+
+// FindGoroutines locates every go statement of the project. Closures are named
+// after their spawner, e.g. "Server.Run$go1".
+func FindGoroutines(p *Project) (*GoroutineReport, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+	report := &GoroutineReport{Entrypoints: make(map[string][]string)}
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			spawner := funcDeclFullName(fd)
+			count := 0
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				stmt, ok := n.(*ast.GoStmt)
+				if !ok {
+					return true
+				}
+				count++
+				spawn := GoroutineSpawn{
+					ID:      fmt.Sprintf("%s$go%d", spawner, count),
+					Spawner: spawner,
+					File:    sf.RelPath,
+					Line:    sf.line(stmt.Pos()),
+				}
+				var roots []string
+				if lit, ok := stmt.Call.Fun.(*ast.FuncLit); ok {
+					spawn.Closure = true
+					spawn.Target = spawn.ID
+					ast.Inspect(lit.Body, func(n ast.Node) bool {
+						if call, ok := n.(*ast.CallExpr); ok {
+							callee := sf.callName(call)
+							roots = append(roots, callee)
+							spawn.Edges = append(spawn.Edges, Edge{From: spawn.Target, To: callee})
+						}
+						return true
+					})
+				} else {
+					spawn.Target = sf.callName(stmt.Call)
+					roots = append(roots, spawn.Target)
+				}
+				spawn.Calls, spawn.Edges = p.goroutineCalls(roots, spawn.Edges)
+				report.Spawns = append(report.Spawns, spawn)
+				return true
+			})
+		}
+	}
+
+	for _, entry := range FindEntrypoints(p) {
+		reachable := reachableFrom(entry)
+		for _, spawn := range report.Spawns {
+			if _, ok := reachable[spawn.Spawner]; ok {
+				report.Entrypoints[entry.Name] = append(report.Entrypoints[entry.Name], spawn.ID)
+			}
+		}
+	}
+	return report, nil
+}
+
+// goroutineCalls returns the functions reachable from roots and the call edges
+// between them, added to the given edges.
+func (p *Project) goroutineCalls(roots []string, edges []Edge) ([]string, []Edge) {
+	seen := make(map[string]bool)
+	queue := append([]string{}, roots...)
+	for _, root := range roots {
+		seen[root] = true
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		node, ok := p.Graph.Nodes[name]
+		if !ok {
+			continue
+		}
+		for _, callee := range sortedKeys(node.Calls) {
+			edges = append(edges, Edge{From: name, To: callee})
+			if !seen[callee] {
+				seen[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return sortedKeys(seen), edges
+}
+
+// String formats the report as text.
+func (r *GoroutineReport) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Goroutines (%d):\n", len(r.Spawns))
+	for _, s := range r.Spawns {
+		fmt.Fprintf(&buf, "  %s: %s starts %s at %s:%d\n", s.ID, s.Spawner, s.Target, s.File, s.Line)
+		if len(s.Calls) > 0 {
+			fmt.Fprintf(&buf, "    calls: %s\n", strings.Join(s.Calls, ", "))
+		}
+	}
+	buf.WriteString("Entrypoints spawning goroutines:\n")
+	for _, entry := range sortedKeys(r.Entrypoints) {
+		fmt.Fprintf(&buf, "  %s: %s\n", entry, strings.Join(r.Entrypoints[entry], ", "))
+	}
+	return buf.String()
+}
+
+// DOT renders each goroutine as a cluster holding its own copy of the call
+// tree, so goroutine boundaries stay visible. Dashed edges are go statements
+// and dotted edges lead from entrypoints to the spawners they reach.
+func (r *GoroutineReport) DOT() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph G {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	buf.WriteString("    node [style=filled, fillcolor=lightgray, shape=rectangle];\n")
+	buf.WriteString("    compound=true;\n")
+
+	outside := make(map[string]bool)
+	for _, s := range r.Spawns {
+		outside[s.Spawner] = true
+	}
+	for entry := range r.Entrypoints {
+		outside[entry] = true
+	}
+	for _, name := range sortedKeys(outside) {
+		color := "lightgray"
+		if _, ok := r.Entrypoints[name]; ok {
+			color = "#AED6F1" // Light blue
+		}
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", fillcolor=\"%s\"];\n", sanitizeIdentifier(name), escapeStringForDOT(name), color))
+	}
+
+	for i, s := range r.Spawns {
+		prefix := fmt.Sprintf("g%d_", i)
+		buf.WriteString(fmt.Sprintf("    subgraph cluster_go_%d {\n", i))
+		buf.WriteString("        style=\"filled,dashed\";\n")
+		buf.WriteString("        color=\"#D5F5E3\";\n")
+		buf.WriteString(fmt.Sprintf("        label=\"%s\";\n", escapeStringForDOT(s.ID)))
+		nodes := map[string]bool{s.Target: true}
+		for _, name := range s.Calls {
+			nodes[name] = true
+		}
+		for _, name := range sortedKeys(nodes) {
+			label := name
+			if name == s.Target && s.Closure {
+				label = "func literal"
+			}
+			buf.WriteString(fmt.Sprintf("        \"%s%s\" [label=\"%s\"];\n", prefix, sanitizeIdentifier(name), escapeStringForDOT(label)))
+		}
+		written := make(map[Edge]bool)
+		for _, e := range s.Edges {
+			if written[e] {
+				continue
+			}
+			written[e] = true
+			buf.WriteString(fmt.Sprintf("        \"%s%s\" -> \"%s%s\";\n", prefix, sanitizeIdentifier(e.From), prefix, sanitizeIdentifier(e.To)))
+		}
+		buf.WriteString("    }\n")
+		buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s%s\" [style=dashed, color=\"#1E8449\", label=\"go\", lhead=cluster_go_%d];\n",
+			sanitizeIdentifier(s.Spawner), prefix, sanitizeIdentifier(s.Target), i))
+	}
+
+	for _, entry := range sortedKeys(r.Entrypoints) {
+		spawners := make(map[string]bool)
+		for _, s := range r.Spawns {
+			for _, id := range r.Entrypoints[entry] {
+				if id == s.ID && s.Spawner != entry {
+					spawners[s.Spawner] = true
+				}
+			}
+		}
+		for _, spawner := range sortedKeys(spawners) {
+			buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\" [style=dotted];\n", sanitizeIdentifier(entry), sanitizeIdentifier(spawner)))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// GoroutinesOptions configures a Goroutines run.
+type GoroutinesOptions struct {
+	Format string // Output format: text, json or dot.
+	Output string // Output file, stdout when empty.
+}
+
+// Goroutines reports the goroutine spawn graph of the project.
+func Goroutines(project string, opts GoroutinesOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	report, err := FindGoroutines(p)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "text":
+		return writeOutput(opts.Output, []byte(report.String()))
+	case "json":
+		return writeJSON(opts.Output, report)
+	case "dot":
+		return writeOutput(opts.Output, report.DOT())
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
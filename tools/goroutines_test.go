@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFindGoroutines tests the FindGoroutines function.
+func TestFindGoroutines(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"main.go": `package main
+
+func main() {
+	Serve()
+}
+
+func Serve() {
+	go worker()
+	go func() {
+		handle()
+	}()
+}
+
+func worker() {
+	process()
+}
+
+func process() {}
+
+func handle() {}
+`,
+	})
+	report, err := FindGoroutines(p)
+	if err != nil {
+		t.Fatalf("FindGoroutines() error = %v", err)
+	}
+
+	tests := []struct {
+		id      string
+		target  string
+		closure bool
+		calls   []string
+		edges   []Edge
+	}{
+		{id: "Serve$go1", target: "worker", calls: []string{"process", "worker"}, edges: []Edge{{From: "worker", To: "process"}}},
+		{id: "Serve$go2", target: "Serve$go2", closure: true, calls: []string{"handle"}, edges: []Edge{{From: "Serve$go2", To: "handle"}}},
+	}
+	if len(report.Spawns) != len(tests) {
+		t.Fatalf("FindGoroutines() found %d spawns, want %d", len(report.Spawns), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			s := report.Spawns[i]
+			if s.ID != tt.id || s.Spawner != "Serve" || s.Target != tt.target || s.Closure != tt.closure {
+				t.Errorf("spawn = %s by %s starting %s (closure %v), want %s by Serve starting %s (closure %v)",
+					s.ID, s.Spawner, s.Target, s.Closure, tt.id, tt.target, tt.closure)
+			}
+			if !reflect.DeepEqual(s.Calls, tt.calls) {
+				t.Errorf("Calls = %v, want %v", s.Calls, tt.calls)
+			}
+			if !reflect.DeepEqual(s.Edges, tt.edges) {
+				t.Errorf("Edges = %v, want %v", s.Edges, tt.edges)
+			}
+		})
+	}
+
+	if want := map[string][]string{"main": {"Serve$go1", "Serve$go2"}}; !reflect.DeepEqual(report.Entrypoints, want) {
+		t.Errorf("Entrypoints = %v, want %v", report.Entrypoints, want)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"edges":[{"from":"worker","to":"process"}]`) {
+		t.Errorf("JSON report lacks the edges of the spawns: %s", data)
+	}
+}
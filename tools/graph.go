@@ -3,40 +3,132 @@ package tools
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"unicode"
 )
 
-func Analyze(project string, outputName string) error {
-	foundFunctions, err := GetFunctions(project)
+// AnalyzeOptions configures the graph rendered by Analyze.
+type AnalyzeOptions struct {
+	Filter FilterOptions
+	Level  string // Aggregation level, see AggregateGraph.
+	Format string // Output format: dot, html, mermaid or json.
+
+	Complexity bool   // Colour nodes by cyclomatic complexity.
+	Cover      string // Coverage profile to colour nodes by and report uncovered functions from.
+	Profile    string // pprof profile to size nodes by and compare with the static calls.
+	SampleType string // Sample type of the profile to use, the profile's default when empty.
+	MaxNodes   int    // Nodes above which a Mermaid flowchart is collapsed to packages, 0 for no limit.
+}
+
+func Analyze(project string, outputName string, opts AnalyzeOptions) error {
+	p, err := LoadProject(project)
 	if err != nil {
 		return err
 	}
-	graph, err := BuildCallGraph(foundFunctions, project)
-	if err != nil {
-		return err
+	if p.snapshot && (opts.Cover != "" || opts.Level == LevelModule) {
+		// Both find the module of each file through the go.mod files of the sources.
+		return fmt.Errorf("coverage and the module level need the go.mod files: %w", errSnapshotSources)
+	}
+	graph := p.Graph
+	if !opts.Filter.IsZero() {
+		graph, err = FilterGraph(graph, opts.Filter)
+		if err != nil {
+			return err
+		}
 	}
 	if outputName == "" {
-		if project == "" {
-			cwd, err := os.Getwd()
-			if err != nil {
-				return err
-			}
-			project = cwd
+		outputName = filepath.Base(p.Root)
+	}
+	var styles []NodeStyle
+	if opts.Complexity {
+		styles = append(styles, ComplexityStyle)
+	}
+	var coverage Coverage
+	if opts.Cover != "" {
+		blocks, err := ParseCoverProfile(opts.Cover)
+		if err != nil {
+			return fmt.Errorf("error reading coverage profile: %w", err)
 		}
-		outputName = strings.Split(project, "/")[len(strings.Split(project, "/"))-1]
+		coverage = ComputeCoverage(p, blocks)
+		styles = append(styles, CoverageStyle(coverage))
 	}
-	err = GenerateDOT(graph, outputName+".dot")
-	if err != nil {
-		return fmt.Errorf("error generating DOT file: %w", err)
+	var overlay *ProfileOverlay
+	if opts.Profile != "" {
+		profile, err := ReadPprof(opts.Profile)
+		if err != nil {
+			return err
+		}
+		overlay, err = BuildProfileOverlay(p.Graph, profile, opts.SampleType)
+		if err != nil {
+			return err
+		}
+		overlay.LabelEdges(graph)
+		styles = append(styles, ProfileStyle(overlay))
 	}
 
-	fmt.Println("Call graph generated in " + outputName + ".dot")
+	switch opts.Format {
+	case "", "dot":
+		graph, err = AggregateGraph(graph, opts.Level, p.Root)
+		if err != nil {
+			return err
+		}
+		err = GenerateDOT(graph, outputName+".dot", styles...)
+		if err != nil {
+			return fmt.Errorf("error generating DOT file: %w", err)
+		}
+		fmt.Println("Call graph generated in " + outputName + ".dot")
+	case "html":
+		groups, err := AggregateKeys(graph, opts.Level, p.Root)
+		if err != nil {
+			return err
+		}
+		err = GenerateHTML(graph, groups, outputName, outputName+".html", styles...)
+		if err != nil {
+			return fmt.Errorf("error generating HTML file: %w", err)
+		}
+		fmt.Println("Call graph generated in " + outputName + ".html")
+	case "mermaid":
+		err = GenerateMermaid(graph, outputName+".mmd", opts.Level, p.Root, opts.MaxNodes, styles...)
+		if err != nil {
+			return fmt.Errorf("error generating Mermaid file: %w", err)
+		}
+		fmt.Println("Call graph generated in " + outputName + ".mmd")
+	case "json":
+		snapshot, err := NewGraphSnapshot(p, graph)
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(outputName+".json", snapshot); err != nil {
+			return fmt.Errorf("error generating JSON file: %w", err)
+		}
+		fmt.Println("Call graph generated in " + outputName + ".json")
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+	if coverage != nil {
+		report, err := coverage.Report()
+		if err != nil {
+			return err
+		}
+		fmt.Print(report)
+	}
+	if overlay != nil {
+		report, err := overlay.Report()
+		if err != nil {
+			return err
+		}
+		fmt.Print(report)
+	}
 	return nil
 }
-func GenerateDOT(graph *CallGraph, filename string) error {
+
+// NodeStyle returns extra DOT attributes for a function node, or nil to keep
+// the default style.
+type NodeStyle func(node *FunctionNode) map[string]string
+
+func GenerateDOT(graph *CallGraph, filename string, styles ...NodeStyle) error {
 	var buf bytes.Buffer
 	buf.WriteString("digraph G {\n")
 	buf.WriteString("    rankdir=LR;\n")
@@ -47,9 +139,19 @@ func GenerateDOT(graph *CallGraph, filename string) error {
 	packageClusters := make(map[string][]*FunctionNode)
 	structClusters := make(map[string][]*FunctionNode)
 	otherNodes := []*FunctionNode{}
+	elidedNodes := []*FunctionNode{}
+	aggregateNodes := []*FunctionNode{}
 
 	// Organize nodes into clusters
 	for _, node := range graph.Nodes {
+		if node.Elided > 0 {
+			elidedNodes = append(elidedNodes, node)
+			continue
+		}
+		if len(node.Members) > 0 {
+			aggregateNodes = append(aggregateNodes, node)
+			continue
+		}
 		// Determine if the function is associated with a struct or package
 		if strings.Contains(node.Name, ".") {
 			parts := strings.Split(node.Name, ".")
@@ -80,7 +182,7 @@ func GenerateDOT(graph *CallGraph, filename string) error {
 		for _, node := range nodes {
 			nodeID := sanitizeIdentifier(node.Name)
 			label := node.Name
-			buf.WriteString(fmt.Sprintf("        \"%s\" [label=\"%s\", shape=rectangle];\n", nodeID, escapeStringForDOT(label)))
+			buf.WriteString(fmt.Sprintf("        \"%s\" [label=\"%s\", shape=rectangle%s];\n", nodeID, escapeStringForDOT(label), styleAttributes(node, styles)))
 		}
 		buf.WriteString("    }\n")
 	}
@@ -105,7 +207,21 @@ func GenerateDOT(graph *CallGraph, filename string) error {
 	for _, node := range otherNodes {
 		nodeID := sanitizeIdentifier(node.Name)
 		label := node.Name
-		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", shape=oval];\n", nodeID, escapeStringForDOT(label)))
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", shape=oval%s];\n", nodeID, escapeStringForDOT(label), styleAttributes(node, styles)))
+	}
+
+	// Write placeholders for functions cut from the graph
+	for _, node := range elidedNodes {
+		nodeID := sanitizeIdentifier(node.Name)
+		label := fmt.Sprintf("… %d more", node.Elided)
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", shape=note, style=dashed];\n", nodeID, escapeStringForDOT(label)))
+	}
+
+	// Write aggregate nodes with the number of functions they contain
+	for _, node := range aggregateNodes {
+		nodeID := sanitizeIdentifier(node.Name)
+		label := fmt.Sprintf("%s\n(%d functions)", node.Name, len(node.Members))
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", shape=box3d];\n", nodeID, escapeStringForDOT(label)))
 	}
 
 	// Write edges
@@ -113,6 +229,24 @@ func GenerateDOT(graph *CallGraph, filename string) error {
 		nodeID := sanitizeIdentifier(node.Name)
 		for _, calledNode := range node.Calls {
 			calledNodeID := sanitizeIdentifier(calledNode.Name)
+			var attrs []string
+			if label, ok := node.CallLabels[calledNode.Name]; ok {
+				attrs = append(attrs, fmt.Sprintf("label=\"%s\"", escapeStringForDOT(label)))
+			} else if count := node.CallCounts[calledNode.Name]; count > 1 {
+				attrs = append(attrs, fmt.Sprintf("label=\"%d\"", count))
+			}
+			for _, tag := range node.CallTags[calledNode.Name] {
+				switch tag {
+				case TagObserved:
+					attrs = append(attrs, "color=\"#1E8449\"", "penwidth=2")
+				case TagRuntimeOnly:
+					attrs = append(attrs, "style=dashed")
+				}
+			}
+			if len(attrs) > 0 {
+				buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\" [%s];\n", nodeID, calledNodeID, strings.Join(attrs, ", ")))
+				continue
+			}
 			buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\";\n", nodeID, calledNodeID))
 		}
 	}
@@ -120,7 +254,23 @@ func GenerateDOT(graph *CallGraph, filename string) error {
 	buf.WriteString("}\n")
 
 	// Write to file
-	return os.WriteFile(filename, buf.Bytes(), 0644)
+	return writeOutput(filename, buf.Bytes())
+}
+
+// styleAttributes formats the attributes the styles return for a node, later
+// styles overriding earlier ones.
+func styleAttributes(node *FunctionNode, styles []NodeStyle) string {
+	attrs := make(map[string]string)
+	for _, style := range styles {
+		for k, v := range style(node) {
+			attrs[k] = v
+		}
+	}
+	var buf strings.Builder
+	for _, k := range sortedKeys(attrs) {
+		buf.WriteString(fmt.Sprintf(", %s=\"%s\"", k, escapeStringForDOT(attrs[k])))
+	}
+	return buf.String()
 }
 
 // Helper function to check if a character is uppercase
@@ -166,13 +316,14 @@ func BuildCallGraph(functions []FunctionInfo, projectRoot string) (*CallGraph, e
 	graph := &CallGraph{Nodes: make(map[string]*FunctionNode)}
 
 	// Create nodes for each function
-	for _, fi := range functions {
+	for i, fi := range functions {
 		funcFullName := getFunctionFullName(fi)
 		if _, exists := graph.Nodes[funcFullName]; !exists {
 			graph.Nodes[funcFullName] = &FunctionNode{
 				Name:     funcFullName,
 				Calls:    make(map[string]*FunctionNode),
 				CalledBy: make(map[string]*FunctionNode),
+				Info:     &functions[i],
 			}
 		}
 	}
@@ -193,15 +344,11 @@ func BuildCallGraph(functions []FunctionInfo, projectRoot string) (*CallGraph, e
 		if err != nil {
 			return nil, err
 		}
+		node.Sites = append(node.Sites, calls...)
 
-		// For each function call, add an edge in the graph
-		for _, call := range calls {
-			calledFuncName := call.Function
-			if call.Receiver != "" {
-				calledFuncName = call.Receiver + "." + call.Function
-			} else if call.Package != "" {
-				calledFuncName = call.Package + "." + call.Function
-			}
+		// For each function call, including calls nested in arguments, add an edge in the graph
+		for _, call := range flattenCalls(calls) {
+			calledFuncName := getCallFullName(call)
 
 			// Ensure the called function node exists
 			if _, exists := graph.Nodes[calledFuncName]; !exists {
@@ -222,6 +369,26 @@ func BuildCallGraph(functions []FunctionInfo, projectRoot string) (*CallGraph, e
 	return graph, nil
 }
 
+// flattenCalls returns the calls and all calls nested in their arguments in source order.
+func flattenCalls(calls []FunctionCallInfo) []FunctionCallInfo {
+	var flat []FunctionCallInfo
+	for _, call := range calls {
+		flat = append(flat, call)
+		flat = append(flat, flattenCalls(call.Calls)...)
+	}
+	return flat
+}
+
+func getCallFullName(call FunctionCallInfo) string {
+	if call.Receiver != "" {
+		return call.Receiver + "." + call.Function
+	}
+	if call.Package != "" {
+		return call.Package + "." + call.Function
+	}
+	return call.Function
+}
+
 func getFunctionFullName(fi FunctionInfo) string {
 	if fi.StructName != "" {
 		return fi.StructName + "." + fi.Name
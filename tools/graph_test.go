@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestBuildCallGraph tests the BuildCallGraph function.
+func TestBuildCallGraph(t *testing.T) {
+	projectRoot := t.TempDir()
+	src := `package main
+
+func main() {
+	result := process(load(), parse("x"))
+	report(result)
+}
+
+func process(a, b int) int {
+	return a + b
+}
+
+func load() int {
+	return 1
+}
+
+func parse(s string) int {
+	return 2
+}
+
+func report(result int) {}
+`
+	if err := os.WriteFile(filepath.Join(projectRoot, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	functions, err := GetFunctions(projectRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, err := BuildCallGraph(functions, projectRoot)
+	if err != nil {
+		t.Fatalf("BuildCallGraph() error = %v", err)
+	}
+
+	tests := []struct {
+		caller string
+		want   []string
+	}{
+		// load and parse are only called in the arguments of process.
+		{caller: "main", want: []string{"load", "parse", "process", "report"}},
+		{caller: "process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller, func(t *testing.T) {
+			node, ok := graph.Nodes[tt.caller]
+			if !ok {
+				t.Fatalf("graph has no node %s", tt.caller)
+			}
+			var calls []string
+			for name := range node.Calls {
+				calls = append(calls, name)
+			}
+			sort.Strings(calls)
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("%s calls %v, want %v", tt.caller, calls, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Hotspot is a function that changes often and is complex or widely used.
+type Hotspot struct {
+	Function     string  `json:"function"`
+	File         string  `json:"file"`
+	Line         int     `json:"line"`
+	Commits      int     `json:"commits"`      // Commits that changed the function.
+	LinesChanged int     `json:"linesChanged"` // Lines added or removed in the function.
+	Cyclomatic   int     `json:"cyclomatic"`
+	FanIn        int     `json:"fanIn"` // Functions calling it.
+	Score        float64 `json:"score"`
+}
+
+// functionChurn counts the changes to a function over the history.
+type functionChurn struct {
+	commits map[string]bool
+	lines   int
+}
+
+// funcRange is the line span of a function declaration in one revision of a file.
+type funcRange struct {
+	name       string
+	start, end int
+}
+
+// FindHotspots reads the git history of the project, attributes the changed
+// lines of every commit to the functions declared in that revision of the file,
+// following file renames, and ranks the current functions by
+// commits × cyclomatic complexity × log2(2 + fan-in).
+func FindHotspots(p *Project, since string, maxCommits int) ([]Hotspot, error) {
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return nil, err
+	}
+	top, err := runGit(root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := runGit(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"log", "-M", "-p", "-U0", "--no-color", "--format=%x00%H"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if maxCommits > 0 {
+		args = append(args, "-n", strconv.Itoa(maxCommits))
+	}
+	args = append(args, "--", "*.go")
+	history, err := runGit(root, args...)
+	if err != nil {
+		return nil, err
+	}
+	blobs, err := newGitBlobs(top)
+	if err != nil {
+		return nil, err
+	}
+	defer blobs.Close()
+
+	churn := make(map[string]*functionChurn)
+	renamed := make(map[string]string) // Older path -> current path.
+	current := func(path string) string {
+		if to, ok := renamed[path]; ok {
+			return to
+		}
+		return path
+	}
+
+	var commit, path, renameFrom string
+	var ranges []funcRange
+	scanner := bufio.NewScanner(strings.NewReader(history))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			commit, path, ranges = strings.TrimPrefix(line, "\x00"), "", nil
+		case strings.HasPrefix(line, "diff --git "):
+			path, renameFrom, ranges = "", "", nil
+		case strings.HasPrefix(line, "rename from "):
+			renameFrom = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to ") && renameFrom != "":
+			// Older commits refer to the file by its previous name.
+			renamed[renameFrom] = current(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "+++ "):
+			path = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			if path == "/dev/null" || strings.HasSuffix(path, "_test.go") {
+				path = ""
+				continue
+			}
+			src, err := blobs.read(commit + ":" + path)
+			if err != nil {
+				return nil, err
+			}
+			ranges = functionRanges(src)
+		case strings.HasPrefix(line, "@@ ") && path != "":
+			start, count, removed, ok := parseHunkHeader(line)
+			if !ok {
+				continue
+			}
+			end := start + count - 1
+			if count == 0 {
+				end = start
+			}
+			file := current(path)
+			for _, r := range ranges {
+				if r.start > end || r.end < start {
+					continue
+				}
+				key := file + "\x00" + r.name
+				if churn[key] == nil {
+					churn[key] = &functionChurn{commits: make(map[string]bool)}
+				}
+				churn[key].commits[commit] = true
+				if count == 0 {
+					churn[key].lines += removed
+				} else {
+					churn[key].lines += min(end, r.end) - max(start, r.start) + 1
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var hotspots []Hotspot
+	for _, fi := range p.Functions {
+		name := getFunctionFullName(fi)
+		c, ok := churn[prefix+filepath.ToSlash(fi.RelativeFilePath)+"\x00"+name]
+		if !ok {
+			continue
+		}
+		h := Hotspot{
+			Function:     name,
+			File:         fi.RelativeFilePath,
+			Line:         fi.LineNumberStart,
+			Commits:      len(c.commits),
+			LinesChanged: c.lines,
+			Cyclomatic:   fi.CyclomaticComplexity,
+		}
+		if node, ok := p.Graph.Nodes[name]; ok {
+			h.FanIn = len(node.CalledBy)
+		}
+		h.Score = float64(h.Commits*h.Cyclomatic) * math.Log2(float64(2+h.FanIn))
+		hotspots = append(hotspots, h)
+	}
+	sort.SliceStable(hotspots, func(i, j int) bool {
+		if hotspots[i].Score != hotspots[j].Score {
+			return hotspots[i].Score > hotspots[j].Score
+		}
+		return hotspots[i].Function < hotspots[j].Function
+	})
+	return hotspots, nil
+}
+
+// functionRanges returns the line spans of the functions declared in a Go source file.
+func functionRanges(src string) []funcRange {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		// Revisions that do not parse cannot be attributed to functions.
+		return nil
+	}
+	var ranges []funcRange
+	for _, decl := range file.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			ranges = append(ranges, funcRange{
+				name:  funcDeclFullName(fd),
+				start: fset.Position(fd.Pos()).Line,
+				end:   fset.Position(fd.End()).Line,
+			})
+		}
+	}
+	return ranges
+}
+
+// parseHunkHeader parses a unified diff hunk header such as "@@ -3,2 +4,5 @@",
+// returning the first new line, the number of added lines and of removed lines.
+func parseHunkHeader(line string) (start, count, removed int, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, 0, false
+	}
+	span := func(field string) (int, int, bool) {
+		from, n, found := strings.Cut(field[1:], ",")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return 0, 0, false
+		}
+		if !found {
+			return first, 1, true
+		}
+		length, err := strconv.Atoi(n)
+		return first, length, err == nil
+	}
+	_, removed, okOld := span(fields[1])
+	start, count, okNew := span(fields[2])
+	return start, count, removed, okOld && okNew
+}
+
+// HotspotStyle colours function nodes by their share of the highest hotspot score.
+func HotspotStyle(hotspots []Hotspot) NodeStyle {
+	scores := make(map[string]Hotspot, len(hotspots))
+	var highest float64
+	for _, h := range hotspots {
+		scores[h.Function] = h
+		highest = math.Max(highest, h.Score)
+	}
+	return func(node *FunctionNode) map[string]string {
+		h, ok := scores[node.Name]
+		if !ok || highest == 0 {
+			return nil
+		}
+		color := "#FEF9E7" // Pale yellow
+		switch heat := h.Score / highest; {
+		case heat > 0.75:
+			color = "#E74C3C" // Red
+		case heat > 0.5:
+			color = "#F0B27A" // Orange
+		case heat > 0.25:
+			color = "#FAD7A0" // Light orange
+		}
+		return map[string]string{
+			"fillcolor": color,
+			"tooltip": fmt.Sprintf("score %.1f: %d commits, cyclomatic %d, fan-in %d",
+				h.Score, h.Commits, h.Cyclomatic, h.FanIn),
+		}
+	}
+}
+
+// HotspotsOptions configures a Hotspots run.
+type HotspotsOptions struct {
+	Since      string // Only read commits more recent than this date, as accepted by git log --since.
+	MaxCommits int    // Most recent commits to read, all when 0.
+	Top        int    // Functions to list, all when 0.
+	Format     string // Output format: text, json or dot.
+	Output     string // Output file, stdout when empty.
+}
+
+// Hotspots ranks the functions of the project by churn, complexity and fan-in.
+func Hotspots(project string, opts HotspotsOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	hotspots, err := FindHotspots(p, opts.Since, opts.MaxCommits)
+	if err != nil {
+		return err
+	}
+	listed := hotspots
+	if opts.Top > 0 && len(listed) > opts.Top {
+		listed = listed[:opts.Top]
+	}
+	switch opts.Format {
+	case "", "text":
+		var buf strings.Builder
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SCORE\tFUNCTION\tCOMMITS\tLINES CHANGED\tCYCLOMATIC\tFAN-IN\tLOCATION")
+		for _, h := range listed {
+			fmt.Fprintf(w, "%.1f\t%s\t%d\t%d\t%d\t%d\t%s:%d\n", h.Score, h.Function, h.Commits, h.LinesChanged, h.Cyclomatic, h.FanIn, h.File, h.Line)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return writeOutput(opts.Output, []byte(buf.String()))
+	case "json":
+		return writeJSON(opts.Output, listed)
+	case "dot":
+		return GenerateDOT(p.Graph, opts.Output, HotspotStyle(hotspots))
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
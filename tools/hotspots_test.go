@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFindHotspots tests the FindHotspots function on a file starting with
+// blank lines, whose functions must keep their line numbers in every revision.
+func TestFindHotspots(t *testing.T) {
+	const v1 = `
+
+package sample
+
+func a(x int) int {
+	if x > 0 {
+		return x
+	}
+	return 0
+}
+
+func b() int {
+	return 1
+}
+`
+	p := loadTestProject(t, map[string]string{"sample.go": v1})
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if _, err := runGit(p.Root, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("add", "sample.go")
+	git("commit", "-q", "-m", "add a and b")
+	v2 := []byte(v1[:len(v1)-len("\treturn 1\n}\n")] + "\treturn 2\n}\n")
+	if err := os.WriteFile(filepath.Join(p.Root, "sample.go"), v2, 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "-q", "-am", "change b")
+
+	blobs, err := newGitBlobs(p.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"HEAD~1:sample.go", "HEAD:sample.go"} {
+		want := v1
+		if object == "HEAD:sample.go" {
+			want = string(v2)
+		}
+		if src, err := blobs.read(object); err != nil || src != want {
+			t.Errorf("read(%s) = %q, %v, want %q", object, src, err, want)
+		}
+	}
+	if _, err := blobs.read("HEAD:missing.go"); err == nil {
+		t.Errorf("read(HEAD:missing.go) succeeded, want an error")
+	}
+	if err := blobs.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	hotspots, err := FindHotspots(p, "", 0)
+	if err != nil {
+		t.Fatalf("FindHotspots() error = %v", err)
+	}
+	got := make(map[string]Hotspot)
+	for _, h := range hotspots {
+		got[h.Function] = h
+	}
+
+	tests := []struct {
+		function     string
+		line         int
+		commits      int
+		linesChanged int
+	}{
+		{function: "a", line: 5, commits: 1, linesChanged: 6},
+		{function: "b", line: 12, commits: 2, linesChanged: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			h, ok := got[tt.function]
+			if !ok {
+				t.Fatalf("%s is not a hotspot: %+v", tt.function, hotspots)
+			}
+			if h.Line != tt.line || h.Commits != tt.commits || h.LinesChanged != tt.linesChanged {
+				t.Errorf("%s = line %d, %d commits, %d lines changed, want line %d, %d commits, %d lines changed",
+					tt.function, h.Line, h.Commits, h.LinesChanged, tt.line, tt.commits, tt.linesChanged)
+			}
+		})
+	}
+}
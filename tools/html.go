@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+)
+
+// htmlGraph is the data rendered by the standalone HTML graph view.
+type htmlGraph struct {
+	Title string     `json:"title"`
+	Nodes []htmlNode `json:"nodes"`
+	Edges []htmlEdge `json:"edges"`
+}
+
+type htmlNode struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	Color   string `json:"color,omitempty"`
+	Tooltip string `json:"tooltip,omitempty"`
+	Group   string `json:"group,omitempty"` // Aggregate the node is collapsed into until expanded.
+}
+
+type htmlEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Color  string `json:"color,omitempty"`
+	Label  string `json:"label,omitempty"`
+	Dashed bool   `json:"dashed,omitempty"`
+}
+
+// GenerateHTML writes a self-contained HTML page rendering the call graph. When
+// groups is not nil, nodes start collapsed into their aggregate, which can be
+// expanded in place by clicking it. Styles set the fillcolor and tooltip of nodes
+// as they do for GenerateDOT.
+func GenerateHTML(graph *CallGraph, groups map[string]string, title, filename string, styles ...NodeStyle) error {
+	g := htmlGraph{Title: title}
+	for _, name := range sortedKeys(graph.Nodes) {
+		node := htmlNode{ID: name, Label: name, Group: groups[name]}
+		for _, style := range styles {
+			attrs := style(graph.Nodes[name])
+			if color, ok := attrs["fillcolor"]; ok {
+				node.Color = color
+			}
+			if tooltip, ok := attrs["tooltip"]; ok {
+				node.Tooltip = tooltip
+			}
+		}
+		if elided := graph.Nodes[name].Elided; elided > 0 {
+			node.Label = fmt.Sprintf("… %d more", elided)
+			node.Color = "white"
+			node.Group = ""
+		}
+		g.Nodes = append(g.Nodes, node)
+		for _, callee := range sortedKeys(graph.Nodes[name].Calls) {
+			g.Edges = append(g.Edges, htmlEdge{From: name, To: callee})
+		}
+	}
+	return writeHTML(g, filename)
+}
+
+// writeHTML renders the graph with an embedded layered layout so the page works
+// offline without any external scripts.
+func writeHTML(g htmlGraph, filename string) error {
+	data, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	err = htmlTemplate.Execute(&buf, struct {
+		Title string
+		Data  template.JS
+	}{Title: g.Title, Data: template.JS(data)})
+	if err != nil {
+		return err
+	}
+	return writeOutput(filename, buf.Bytes())
+}
+
+var htmlTemplate = template.Must(template.New("graph").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font-family: sans-serif; }
+header { padding: 8px 12px; background: #f4f4f4; border-bottom: 1px solid #ccc; }
+svg { width: 100vw; height: calc(100vh - 40px); cursor: grab; }
+.node rect { stroke: #555; rx: 4; }
+.node text { font-size: 12px; pointer-events: none; }
+.edge { fill: none; stroke-width: 1.2; }
+</style>
+</head>
+<body>
+<header>{{.Title}} &mdash; scroll to zoom, drag to pan</header>
+<svg id="graph"><defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="#777"/></marker></defs><g id="viewport"></g></svg>
+<script>
+const graph = {{.Data}};
+const svgNS = "http://www.w3.org/2000/svg";
+const svg = document.getElementById("graph");
+const viewport = document.getElementById("viewport");
+
+function layout(nodes, edges) {
+  // Assign each node to a layer one past its deepest caller, ignoring back edges.
+  const layer = {}, outgoing = {}, indegree = {};
+  nodes.forEach(n => { layer[n.id] = 0; outgoing[n.id] = []; indegree[n.id] = 0; });
+  edges.forEach(e => { if (e.from !== e.to && outgoing[e.from] && e.to in indegree) { outgoing[e.from].push(e.to); indegree[e.to]++; } });
+  const queue = nodes.filter(n => indegree[n.id] === 0).map(n => n.id);
+  const seen = new Set(queue);
+  while (queue.length) {
+    const id = queue.shift();
+    outgoing[id].forEach(to => {
+      layer[to] = Math.max(layer[to], layer[id] + 1);
+      if (--indegree[to] === 0 && !seen.has(to)) { seen.add(to); queue.push(to); }
+    });
+  }
+  const columns = {}, pos = {};
+  nodes.forEach(n => { (columns[layer[n.id]] = columns[layer[n.id]] || []).push(n); });
+  Object.keys(columns).forEach(l => columns[l].forEach((n, i) => { pos[n.id] = { x: 40 + l * 260, y: 40 + i * 44 }; }));
+  return pos;
+}
+
+const expanded = new Set();
+
+// visibleGraph replaces every node of a collapsed group with the group node and
+// merges the edges between groups, counting the calls behind them.
+function visibleGraph() {
+  const rep = {}, nodes = [], groupSize = {}, added = new Set();
+  graph.nodes.forEach(n => { if (n.group) groupSize[n.group] = (groupSize[n.group] || 0) + 1; });
+  graph.nodes.forEach(n => {
+    if (n.group && !expanded.has(n.group)) {
+      rep[n.id] = "group:" + n.group;
+      if (!added.has(rep[n.id])) {
+        added.add(rep[n.id]);
+        nodes.push({ id: rep[n.id], label: n.group + " (" + groupSize[n.group] + ")", color: "#D6EAF8", tooltip: "Click to expand " + n.group, expand: n.group });
+      }
+      return;
+    }
+    rep[n.id] = n.id;
+    nodes.push(n.group ? Object.assign({}, n, { tooltip: n.id + " in " + n.group + " (click to collapse)", collapse: n.group }) : n);
+  });
+  const edges = {}, order = [];
+  graph.edges.forEach(e => {
+    const from = rep[e.from], to = rep[e.to];
+    if (from === undefined || to === undefined || (from === to && from !== e.from)) return;
+    const key = from + "\u0000" + to;
+    if (!edges[key]) { edges[key] = Object.assign({}, e, { from: from, to: to, count: 0 }); order.push(key); }
+    edges[key].count++;
+  });
+  return { nodes: nodes, edges: order.map(k => { const e = edges[k]; if (e.count > 1) e.label = e.count + " calls"; return e; }) };
+}
+
+function render() {
+  viewport.innerHTML = "";
+  const visible = visibleGraph();
+  const pos = layout(visible.nodes, visible.edges);
+  const width = {};
+  visible.nodes.forEach(n => { width[n.id] = Math.max(80, n.label.length * 7 + 16); });
+  visible.edges.forEach(e => {
+    const a = pos[e.from], b = pos[e.to];
+    if (!a || !b) return;
+    const path = document.createElementNS(svgNS, "path");
+    const x1 = a.x + width[e.from], y1 = a.y + 14, x2 = b.x, y2 = b.y + 14;
+    const dx = Math.max(40, Math.abs(x2 - x1) / 2);
+    path.setAttribute("d", "M" + x1 + "," + y1 + " C" + (x1 + dx) + "," + y1 + " " + (x2 - dx) + "," + y2 + " " + x2 + "," + y2);
+    path.setAttribute("class", "edge");
+    path.setAttribute("stroke", e.color || "#999");
+    path.setAttribute("marker-end", "url(#arrow)");
+    if (e.count > 1) path.setAttribute("stroke-width", Math.min(6, 1 + Math.log2(e.count)));
+    if (e.dashed) path.setAttribute("stroke-dasharray", "4,3");
+    if (e.label) { const t = document.createElementNS(svgNS, "title"); t.textContent = e.label; path.appendChild(t); }
+    viewport.appendChild(path);
+  });
+  visible.nodes.forEach(n => {
+    const g = document.createElementNS(svgNS, "g");
+    g.setAttribute("class", "node");
+    g.setAttribute("transform", "translate(" + pos[n.id].x + "," + pos[n.id].y + ")");
+    const rect = document.createElementNS(svgNS, "rect");
+    rect.setAttribute("width", width[n.id]);
+    rect.setAttribute("height", 28);
+    rect.setAttribute("fill", n.color || "lightgray");
+    const text = document.createElementNS(svgNS, "text");
+    text.setAttribute("x", 8);
+    text.setAttribute("y", 18);
+    text.textContent = n.label;
+    const title = document.createElementNS(svgNS, "title");
+    title.textContent = n.tooltip || n.id;
+    g.append(rect, text, title);
+    if (n.expand || n.collapse) {
+      g.style.cursor = "pointer";
+      g.addEventListener("click", () => {
+        if (n.expand) expanded.add(n.expand); else expanded.delete(n.collapse);
+        render();
+      });
+    }
+    viewport.appendChild(g);
+  });
+}
+
+let view = { x: 0, y: 0, k: 1 }, drag = null;
+function applyView() { viewport.setAttribute("transform", "translate(" + view.x + "," + view.y + ") scale(" + view.k + ")"); }
+svg.addEventListener("wheel", ev => { ev.preventDefault(); view.k *= ev.deltaY < 0 ? 1.1 : 0.9; applyView(); });
+svg.addEventListener("mousedown", ev => { drag = { x: ev.clientX - view.x, y: ev.clientY - view.y }; });
+svg.addEventListener("mousemove", ev => { if (drag) { view.x = ev.clientX - drag.x; view.y = ev.clientY - drag.y; applyView(); } });
+window.addEventListener("mouseup", () => { drag = null; });
+render();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// DefaultMermaidNodes is the number of nodes above which a Mermaid flowchart is
+// collapsed to packages, as larger diagrams fail to render or become unreadable.
+const DefaultMermaidNodes = 150
+
+// mermaidSubgraph is a package or type subgraph of a Mermaid flowchart.
+type mermaidSubgraph struct {
+	label    string
+	nodes    []*FunctionNode
+	children map[string]*mermaidSubgraph
+}
+
+func newMermaidSubgraph(label string) *mermaidSubgraph {
+	return &mermaidSubgraph{label: label, children: make(map[string]*mermaidSubgraph)}
+}
+
+func (s *mermaidSubgraph) child(key, label string) *mermaidSubgraph {
+	if _, ok := s.children[key]; !ok {
+		s.children[key] = newMermaidSubgraph(label)
+	}
+	return s.children[key]
+}
+
+// GenerateMermaid writes the call graph, aggregated at the given level, as a
+// Mermaid flowchart with a subgraph per package holding a subgraph per type.
+// Graphs of more than maxNodes nodes are collapsed to packages, then to
+// top-level directories, noting it in a comment; maxNodes of 0 disables the
+// collapse. Styles set the fill of nodes.
+func GenerateMermaid(graph *CallGraph, filename, level, projectRoot string, maxNodes int, styles ...NodeStyle) error {
+	var buf bytes.Buffer
+	buf.WriteString("flowchart LR\n")
+
+	// Every level is aggregated from the function graph, as aggregates no
+	// longer know the packages of their functions.
+	functions := graph
+	graph, err := AggregateGraph(functions, level, projectRoot)
+	if err != nil {
+		return err
+	}
+	for _, collapse := range []string{LevelPackage, LevelDirectory} {
+		if maxNodes <= 0 || len(graph.Nodes) <= maxNodes {
+			break
+		}
+		if !coarserLevel(collapse, level) {
+			continue
+		}
+		collapsed, err := AggregateGraph(functions, collapse, projectRoot)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "    %%%% %d nodes collapsed to %d at the %s level\n", len(graph.Nodes), len(collapsed.Nodes), collapse)
+		graph = collapsed
+	}
+
+	// Group the functions of the project by package and type, and the
+	// functions outside it by qualifier.
+	root := newMermaidSubgraph("")
+	for _, name := range sortedKeys(graph.Nodes) {
+		node := graph.Nodes[name]
+		switch {
+		case node.Elided > 0 || len(node.Members) > 0:
+			root.nodes = append(root.nodes, node)
+		case node.Info != nil:
+			pkg := root.child("pkg_"+packageKey(node.Info), "Package: "+packageKey(node.Info))
+			if node.Info.StructName != "" {
+				typeName := strings.TrimPrefix(node.Info.StructName, "*")
+				pkg = pkg.child("type_"+packageKey(node.Info)+"."+typeName, "Type: "+typeName)
+			}
+			pkg.nodes = append(pkg.nodes, node)
+		default:
+			key := externalKey(name)
+			if key == "external:builtin" {
+				root.nodes = append(root.nodes, node)
+				continue
+			}
+			ext := root.child(key, "External: "+strings.TrimPrefix(key, "external:"))
+			ext.nodes = append(ext.nodes, node)
+		}
+	}
+
+	var writeSubgraph func(s *mermaidSubgraph, indent string)
+	writeSubgraph = func(s *mermaidSubgraph, indent string) {
+		for _, node := range s.nodes {
+			fmt.Fprintf(&buf, "%s%s\n", indent, mermaidNode(node))
+		}
+		for _, key := range sortedKeys(s.children) {
+			child := s.children[key]
+			fmt.Fprintf(&buf, "%ssubgraph %s[\"%s\"]\n", indent, sanitizeIdentifier(key), escapeStringForMermaid(child.label))
+			writeSubgraph(child, indent+"    ")
+			fmt.Fprintf(&buf, "%send\n", indent)
+		}
+	}
+	writeSubgraph(root, "    ")
+
+	// Write the edges, styling observed calls by index.
+	link := 0
+	var observed []string
+	for _, name := range sortedKeys(graph.Nodes) {
+		node := graph.Nodes[name]
+		for _, callee := range sortedKeys(node.Calls) {
+			arrow := "-->"
+			for _, tag := range node.CallTags[callee] {
+				switch tag {
+				case TagObserved:
+					observed = append(observed, fmt.Sprint(link))
+				case TagRuntimeOnly:
+					arrow = "-.->"
+				}
+			}
+			if label, ok := node.CallLabels[callee]; ok {
+				arrow += fmt.Sprintf("|\"%s\"|", escapeStringForMermaid(label))
+			} else if count := node.CallCounts[callee]; count > 1 {
+				arrow += fmt.Sprintf("|%d|", count)
+			}
+			fmt.Fprintf(&buf, "    %s %s %s\n", sanitizeIdentifier(name), arrow, sanitizeIdentifier(callee))
+			link++
+		}
+	}
+	if len(observed) > 0 {
+		fmt.Fprintf(&buf, "    linkStyle %s stroke:#1E8449,stroke-width:2px\n", strings.Join(observed, ","))
+	}
+
+	for _, name := range sortedKeys(graph.Nodes) {
+		node := graph.Nodes[name]
+		fill := ""
+		for _, style := range styles {
+			if color, ok := style(node)["fillcolor"]; ok {
+				fill = color
+			}
+		}
+		if fill != "" {
+			fmt.Fprintf(&buf, "    style %s fill:%s\n", sanitizeIdentifier(name), fill)
+		}
+	}
+
+	return writeOutput(filename, buf.Bytes())
+}
+
+// coarserLevel reports whether aggregating at level a groups more functions
+// together than at level b. Modules are not compared to directories and are
+// never collapsed further.
+func coarserLevel(a, b string) bool {
+	order := []string{LevelFunction, LevelType, LevelFile, LevelPackage, LevelDirectory}
+	rank := func(level string) int {
+		if level == "" {
+			return 0
+		}
+		for i, l := range order {
+			if l == level {
+				return i
+			}
+		}
+		return len(order)
+	}
+	return rank(a) > rank(b)
+}
+
+// mermaidNode formats the declaration of a node: rectangles for project
+// functions, rounded boxes for others, hexagons for placeholders and
+// subroutine boxes for aggregates.
+func mermaidNode(node *FunctionNode) string {
+	id := sanitizeIdentifier(node.Name)
+	switch {
+	case node.Elided > 0:
+		return fmt.Sprintf("%s{{\"… %d more\"}}", id, node.Elided)
+	case len(node.Members) > 0:
+		return fmt.Sprintf("%s[[\"%s<br/>(%d functions)\"]]", id, escapeStringForMermaid(node.Name), len(node.Members))
+	case node.Info != nil:
+		return fmt.Sprintf("%s[\"%s\"]", id, escapeStringForMermaid(node.Name))
+	default:
+		return fmt.Sprintf("%s(\"%s\")", id, escapeStringForMermaid(node.Name))
+	}
+}
+
+// escapeStringForMermaid escapes a quoted Mermaid label, which takes entity
+// codes for the characters that would end it or be read as markup.
+func escapeStringForMermaid(s string) string {
+	return strings.NewReplacer(
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+		"\n", "<br/>",
+		"\r", "",
+	).Replace(s)
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateMermaid tests the GenerateMermaid function.
+func TestGenerateMermaid(t *testing.T) {
+	graph := newTestGraph(Edge{"main", "*Server.Run"}, Edge{"*Server.Run", "end"}, Edge{"end", "fmt.Println"}, Edge{"end", "*Store.Get"})
+	graph.Nodes["main"].Info = &FunctionInfo{Name: "main", PkgName: "main", RelativeFilePath: "main.go"}
+	graph.Nodes["*Server.Run"].Info = &FunctionInfo{Name: "Run", StructName: "*Server", PkgName: "server", RelativeFilePath: "server/server.go"}
+	graph.Nodes["end"].Info = &FunctionInfo{Name: "end", PkgName: "server", RelativeFilePath: "server/server.go"}
+	graph.Nodes["*Store.Get"].Info = &FunctionInfo{Name: "Get", StructName: "*Store", PkgName: "store", RelativeFilePath: "server/store/store.go"}
+	graph.Nodes["fmt.Println"].Info = nil
+	graph.Nodes["main"].CallTags = map[string][]string{"*Server.Run": {TagObserved, TagRuntimeOnly}}
+
+	tests := []struct {
+		name     string
+		level    string
+		maxNodes int
+		want     []string
+		notWant  []string
+	}{
+		{
+			name: "functions",
+			want: []string{
+				"flowchart LR\n",
+				"    subgraph pkg_server_10[\"Package: server\"]\n        end_3[\"end\"]\n        subgraph type_server_Server_18[\"Type: Server\"]\n            _Server_Run_11[\"*Server.Run\"]\n        end\n    end\n",
+				"    subgraph external_fmt_12[\"External: fmt\"]\n        fmt_Println_11(\"fmt.Println\")\n    end\n",
+				"    main_4 -.-> _Server_Run_11\n",
+				"    linkStyle 3 stroke:#1E8449,stroke-width:2px\n",
+			},
+			notWant: []string{"%%"},
+		},
+		{
+			name:     "collapsed",
+			maxNodes: 4,
+			want: []string{
+				"    %% 5 nodes collapsed to 4 at the package level\n",
+				"    server_6[[\"server<br/>(2 functions)\"]]\n",
+				"    server_6 --> external_fmt_12\n",
+			},
+			notWant: []string{"subgraph pkg_"},
+		},
+		{
+			name:     "collapsed twice",
+			maxNodes: 3,
+			want: []string{
+				"    %% 5 nodes collapsed to 4 at the package level\n",
+				"    %% 4 nodes collapsed to 3 at the directory level\n",
+				"    server__7[[\"server/<br/>(3 functions)\"]]\n",
+			},
+			notWant: []string{"external:external", "external:builtin", "subgraph pkg_"},
+		},
+		{
+			name:     "aggregated then collapsed",
+			level:    LevelPackage,
+			maxNodes: 3,
+			want: []string{
+				"    %% 4 nodes collapsed to 3 at the directory level\n",
+			},
+			notWant: []string{"at the package level", "external:external", "external:builtin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "graph.mmd")
+			if err := GenerateMermaid(graph, filename, tt.level, "", tt.maxNodes); err != nil {
+				t.Fatalf("GenerateMermaid() error = %v", err)
+			}
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("GenerateMermaid() output lacks %q:\n%s", want, data)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(string(data), notWant) {
+					t.Errorf("GenerateMermaid() output contains %q:\n%s", notWant, data)
+				}
+			}
+		})
+	}
+}
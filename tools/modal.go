@@ -7,15 +7,15 @@ import (
 
 // FunctionCallInfo represents a function call within a function.
 type FunctionCallInfo struct {
-	Function   string             // The function being called, including receiver if any.
-	Line       int                // Line number where the call occurs.
-	Calls      []FunctionCallInfo // Nested function calls within arguments.
-	FullExpr   string             // The full expression of the function call.
-	Package    string             // Package name if available.
-	Receiver   string             // Receiver type or variable name if it's a method call.
-	Arguments  []string           // Argument expressions as strings.
-	FilePath   string             // The file where the function call is located.
-	StructName string             // Struct name if it's a method within a struct.
+	Function   string             `json:"function"`             // The function being called, including receiver if any.
+	Line       int                `json:"line"`                 // Line number where the call occurs.
+	Calls      []FunctionCallInfo `json:"calls,omitempty"`      // Nested function calls within arguments.
+	FullExpr   string             `json:"expr"`                 // The full expression of the function call.
+	Package    string             `json:"package,omitempty"`    // Package name if available.
+	Receiver   string             `json:"receiver,omitempty"`   // Receiver type or variable name if it's a method call.
+	Arguments  []string           `json:"arguments,omitempty"`  // Argument expressions as strings.
+	FilePath   string             `json:"file"`                 // The file where the function call is located, relative to the project root.
+	StructName string             `json:"structName,omitempty"` // Struct name if it's a method within a struct.
 }
 
 func (fci *FunctionCallInfo) String(indent int) string {
@@ -42,4 +42,14 @@ type FunctionNode struct {
 	Name     string
 	Calls    map[string]*FunctionNode
 	CalledBy map[string]*FunctionNode
+	Info     *FunctionInfo      // Declaration details, nil for functions outside the project.
+	Sites    []FunctionCallInfo // Calls the function makes, as GetFunctionCalls returns them.
+	Elided   int                // Number of functions a placeholder node stands for, 0 for real functions.
+
+	Members    []string       // Functions collapsed into an aggregate node.
+	CallCounts map[string]int // Number of function-level calls behind each edge of an aggregate node.
+
+	CallTags   map[string][]string   // Tags of the call to each callee, such as TagObserved.
+	CallLabels map[string]string     // Label of the call to each callee, such as its latency.
+	CallSites  map[string][]Position // Positions of the calls to each callee, set when loaded from a snapshot.
 }
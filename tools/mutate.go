@@ -0,0 +1,337 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Kinds of mutation.
+const (
+	MutationConditional = "conditional" // Negates a comparison or swaps && and ||.
+	MutationBoundary    = "boundary"    // Moves the boundary of an ordered comparison.
+	MutationDropCall    = "drop-call"   // Removes a call statement.
+)
+
+// Outcomes of a mutant.
+const (
+	MutantKilled   = "killed"   // A test failed.
+	MutantSurvived = "survived" // Every test passed.
+	MutantInvalid  = "invalid"  // The mutated code does not compile.
+	MutantUntested = "untested" // No test reaches the function.
+)
+
+// conditionalMutations and boundaryMutations map an operator to its replacement.
+var (
+	conditionalMutations = map[token.Token]token.Token{
+		token.EQL:  token.NEQ,
+		token.NEQ:  token.EQL,
+		token.LSS:  token.GEQ,
+		token.GEQ:  token.LSS,
+		token.GTR:  token.LEQ,
+		token.LEQ:  token.GTR,
+		token.LAND: token.LOR,
+		token.LOR:  token.LAND,
+	}
+	boundaryMutations = map[token.Token]token.Token{
+		token.LSS: token.LEQ,
+		token.LEQ: token.LSS,
+		token.GTR: token.GEQ,
+		token.GEQ: token.GTR,
+	}
+)
+
+// Mutant is a single change to the source of a function.
+type Mutant struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Kind     string `json:"kind"`
+	Original string `json:"original"`
+	Mutated  string `json:"mutated"`
+	Status   string `json:"status"`
+
+	start, end int // Byte offsets of the replaced source.
+}
+
+// MutationReport is the outcome of a mutation testing run.
+type MutationReport struct {
+	Mutants  []Mutant `json:"mutants"`
+	Killed   int      `json:"killed"`
+	Survived int      `json:"survived"`
+	Invalid  int      `json:"invalid"`
+	Untested int      `json:"untested"`
+}
+
+// Score returns the share of the mutants the tests ran against that they killed.
+func (r *MutationReport) Score() float64 {
+	if r.Killed+r.Survived == 0 {
+		return 0
+	}
+	return float64(r.Killed) / float64(r.Killed+r.Survived)
+}
+
+// matchesFunction reports whether the call graph name of a function matches a
+// glob pattern, with or without the pointer of its receiver.
+func matchesFunction(pattern, name string) bool {
+	for _, candidate := range []string{name, strings.TrimPrefix(name, "*")} {
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// findMutants returns the mutants of a function declaration.
+func findMutants(sf *sourceFile, fd *ast.FuncDecl) []Mutant {
+	name := funcDeclFullName(fd)
+	var mutants []Mutant
+	add := func(kind string, pos, end token.Pos, mutated string) {
+		start, stop := sf.Fset.Position(pos).Offset, sf.Fset.Position(end).Offset
+		mutants = append(mutants, Mutant{
+			Function: name,
+			File:     sf.RelPath,
+			Line:     sf.line(pos),
+			Kind:     kind,
+			Original: string(sf.Src[start:stop]),
+			Mutated:  mutated,
+			start:    start,
+			end:      stop,
+		})
+	}
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BinaryExpr:
+			end := n.OpPos + token.Pos(len(n.Op.String()))
+			if op, ok := conditionalMutations[n.Op]; ok {
+				add(MutationConditional, n.OpPos, end, op.String())
+			}
+			if op, ok := boundaryMutations[n.Op]; ok {
+				add(MutationBoundary, n.OpPos, end, op.String())
+			}
+		case *ast.ExprStmt:
+			if _, ok := n.X.(*ast.CallExpr); ok {
+				add(MutationDropCall, n.Pos(), n.End(), "")
+			}
+		}
+		return true
+	})
+	return mutants
+}
+
+// FindMutants returns the mutants of the project functions matching a glob pattern.
+func FindMutants(p *Project, pattern string) ([]Mutant, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+	var mutants []Mutant
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			if matchesFunction(pattern, funcDeclFullName(fd)) {
+				mutants = append(mutants, findMutants(sf, fd)...)
+			}
+		}
+	}
+	sort.SliceStable(mutants, func(i, j int) bool {
+		if mutants[i].File != mutants[j].File {
+			return mutants[i].File < mutants[j].File
+		}
+		return mutants[i].start < mutants[j].start
+	})
+	return mutants, nil
+}
+
+// testCommand returns the go test arguments running the given tests, which are
+// run with -run so benchmarks are left out.
+func testCommand(tests []TestReach, timeout time.Duration) []string {
+	names := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, t := range tests {
+		if strings.HasPrefix(t.Name, "Benchmark") {
+			continue
+		}
+		names[regexp.QuoteMeta(t.Name)] = true
+		dirs["./"+filepath.ToSlash(filepath.Dir(t.File))] = true
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	args := []string{"test", "-count=1", "-timeout", timeout.String(), "-run", "^(" + strings.Join(sortedKeys(names), "|") + ")$"}
+	return append(args, sortedKeys(dirs)...)
+}
+
+// runTests runs go test in dir and reports whether the tests passed and whether
+// the packages built.
+func runTests(dir string, args []string) (passed, built bool, output string) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	output = string(out)
+	built = !strings.Contains(output, "[build failed]") && !strings.Contains(output, "[setup failed]")
+	return err == nil, built, output
+}
+
+// RunMutants applies the mutants of the functions matching a glob pattern one at a
+// time to a temporary copy of the module and runs the tests the call graph says
+// reach each function against them.
+func RunMutants(p *Project, pattern string, timeout time.Duration) (*MutationReport, error) {
+	mutants, err := FindMutants(p, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(mutants) == 0 {
+		return nil, fmt.Errorf("no mutants in the functions matching %s", pattern)
+	}
+	tm, err := BuildTestMap(p, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return nil, err
+	}
+	moduleRoot := findModuleRoot(root)
+	rel, err := filepath.Rel(moduleRoot, root)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "gpa-mutate-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := copyTree(moduleRoot, tmp); err != nil {
+		return nil, fmt.Errorf("failed to copy the module: %w", err)
+	}
+	mutated := filepath.Join(tmp, rel)
+
+	report := &MutationReport{}
+	baseline := make(map[string]bool) // Functions whose tests pass unmutated.
+	for i, m := range mutants {
+		args := testCommand(tm.Functions[m.Function], timeout)
+		if args == nil {
+			m.Status = MutantUntested
+		} else {
+			if !baseline[m.Function] {
+				if passed, _, output := runTests(mutated, args); !passed {
+					return nil, fmt.Errorf("the tests reaching %s fail without mutations:\n%s", m.Function, output)
+				}
+				baseline[m.Function] = true
+			}
+			if m.Status, err = runMutant(mutated, m, args); err != nil {
+				return nil, err
+			}
+		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s:%d %s %s\n", i+1, len(mutants), m.File, m.Line, m.Kind, m.Status)
+
+		switch m.Status {
+		case MutantKilled:
+			report.Killed++
+		case MutantSurvived:
+			report.Survived++
+		case MutantInvalid:
+			report.Invalid++
+		case MutantUntested:
+			report.Untested++
+		}
+		report.Mutants = append(report.Mutants, m)
+	}
+	return report, nil
+}
+
+// runMutant writes the mutated file to the copy of the project at dir, runs
+// the tests and restores the file.
+func runMutant(dir string, m Mutant, args []string) (string, error) {
+	filename := filepath.Join(dir, m.File)
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	mutated := append(append(append([]byte{}, src[:m.start]...), m.Mutated...), src[m.end:]...)
+	if err := os.WriteFile(filename, mutated, 0644); err != nil {
+		return "", err
+	}
+	passed, built, _ := runTests(dir, args)
+	if err := os.WriteFile(filename, src, 0644); err != nil {
+		return "", err
+	}
+	switch {
+	case !built:
+		return MutantInvalid, nil
+	case passed:
+		return MutantSurvived, nil
+	default:
+		return MutantKilled, nil
+	}
+}
+
+// Report formats the mutation report as text, listing the mutants no test killed.
+func (r *MutationReport) Report() (string, error) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d mutants: %d killed, %d survived, %d invalid, %d untested (score %.1f%%)\n",
+		len(r.Mutants), r.Killed, r.Survived, r.Invalid, r.Untested, 100*r.Score())
+
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tLOCATION\tFUNCTION\tKIND\tCHANGE")
+	for _, m := range r.Mutants {
+		if m.Status != MutantSurvived && m.Status != MutantUntested {
+			continue
+		}
+		change := fmt.Sprintf("%s -> %s", m.Original, m.Mutated)
+		if m.Kind == MutationDropCall {
+			change = "removed " + m.Original
+		}
+		fmt.Fprintf(w, "%s\t%s:%d\t%s\t%s\t%s\n", m.Status, m.File, m.Line, m.Function, m.Kind, strings.Join(strings.Fields(change), " "))
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// MutateOptions configures a Mutate run.
+type MutateOptions struct {
+	Function string        // Glob pattern of the functions to mutate.
+	Timeout  time.Duration // Timeout of the tests run against each mutant.
+	Format   string        // Output format: text or json.
+	Output   string        // Output file, stdout when empty.
+}
+
+// Mutate runs mutation testing on the project functions matching a pattern,
+// running only the tests that reach them.
+func Mutate(project string, opts MutateOptions) error {
+	if opts.Function == "" {
+		return fmt.Errorf("no functions to mutate")
+	}
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	report, err := RunMutants(p, opts.Function, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "text":
+		text, err := report.Report()
+		if err != nil {
+			return err
+		}
+		return writeOutput(opts.Output, []byte(text))
+	case "json":
+		return writeJSON(opts.Output, report)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
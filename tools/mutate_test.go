@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFindMutants tests the FindMutants function.
+func TestFindMutants(t *testing.T) {
+	src := `package sample
+
+func inRange(v, low, high int) bool {
+	return v >= low && v < high
+}
+
+func notify(v int) {
+	if v == 0 {
+		log(v)
+	}
+}
+
+func log(v int) {}
+`
+	p := loadTestProject(t, map[string]string{"sample.go": src})
+
+	type mutation struct{ kind, original, mutated string }
+	tests := []struct {
+		pattern string
+		want    []mutation
+	}{
+		{pattern: "inRange", want: []mutation{
+			{MutationConditional, ">=", "<"},
+			{MutationBoundary, ">=", ">"},
+			{MutationConditional, "&&", "||"},
+			{MutationConditional, "<", ">="},
+			{MutationBoundary, "<", "<="},
+		}},
+		{pattern: "no*", want: []mutation{
+			{MutationConditional, "==", "!="},
+			{MutationDropCall, "log(v)", ""},
+		}},
+		{pattern: "missing", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			mutants, err := FindMutants(p, tt.pattern)
+			if err != nil {
+				t.Fatalf("FindMutants() error = %v", err)
+			}
+			if len(mutants) != len(tt.want) {
+				t.Fatalf("FindMutants() = %+v, want %d mutants", mutants, len(tt.want))
+			}
+			for i, m := range mutants {
+				if got := (mutation{m.Kind, m.Original, m.Mutated}); got != tt.want[i] {
+					t.Errorf("mutant %d = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestTestCommand tests the testCommand function.
+func TestTestCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		tests []TestReach
+		want  []string
+	}{
+		{
+			name: "Tests of two packages",
+			tests: []TestReach{
+				{Name: "TestLoad", File: "store/store_test.go"},
+				{Name: "TestRun", File: "main_test.go"},
+				{Name: "BenchmarkRun", File: "main_test.go"},
+			},
+			want: []string{"test", "-count=1", "-timeout", "1m0s", "-run", "^(TestLoad|TestRun)$", "./.", "./store"},
+		},
+		{
+			name:  "Names are quoted",
+			tests: []TestReach{{Name: "TestA.B", File: "a_test.go"}},
+			want:  []string{"test", "-count=1", "-timeout", "1m0s", "-run", `^(TestA\.B)$`, "./."},
+		},
+		{
+			name:  "Only benchmarks",
+			tests: []TestReach{{Name: "BenchmarkRun", File: "main_test.go"}},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testCommand(tt.tests, time.Minute); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("testCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunMutants tests that RunMutants runs only the tests reaching each
+// mutated function and classifies the mutants by their outcome.
+func TestRunMutants(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test against every mutant")
+	}
+	p := loadTestProject(t, map[string]string{
+		"go.mod": "module example.com/sample\n\ngo 1.21\n",
+		"sample.go": `package sample
+
+func Clamp(v int) int {
+	if v > 10 {
+		return 10
+	}
+	return v
+}
+
+func Log(msg string) {
+	n := len(msg)
+	use(n)
+}
+
+func Untested(v int) bool {
+	return v == 0
+}
+
+func Broken(v int) bool {
+	return v > 0
+}
+
+func use(n int) {}
+`,
+		"sample_test.go": `package sample
+
+import "testing"
+
+func TestClamp(t *testing.T) {
+	if Clamp(20) != 10 || Clamp(3) != 3 {
+		t.Fatal("Clamp does not clamp")
+	}
+}
+
+func TestLog(t *testing.T) {
+	Log("x")
+}
+
+func TestBroken(t *testing.T) {
+	Broken(1)
+	t.Fatal("always fails")
+}
+`,
+	})
+
+	// TestBroken fails, so it must not run against the mutants of the other functions.
+	report, err := RunMutants(p, "[CLU]*", time.Minute)
+	if err != nil {
+		t.Fatalf("RunMutants() error = %v", err)
+	}
+	type outcome struct{ function, kind, mutated, status string }
+	want := []outcome{
+		{"Clamp", MutationConditional, "<=", MutantKilled},
+		{"Clamp", MutationBoundary, ">=", MutantSurvived},
+		{"Log", MutationDropCall, "", MutantInvalid},
+		{"Untested", MutationConditional, "!=", MutantUntested},
+	}
+	var got []outcome
+	for _, m := range report.Mutants {
+		got = append(got, outcome{m.Function, m.Kind, m.Mutated, m.Status})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RunMutants() mutants = %+v, want %+v", got, want)
+	}
+	if report.Killed != 1 || report.Survived != 1 || report.Invalid != 1 || report.Untested != 1 {
+		t.Errorf("RunMutants() = %d killed, %d survived, %d invalid, %d untested, want one of each",
+			report.Killed, report.Survived, report.Invalid, report.Untested)
+	}
+
+	if _, err := RunMutants(p, "Broken", time.Minute); err == nil || !strings.Contains(err.Error(), "fail without mutations") {
+		t.Errorf("RunMutants() of a function with failing tests error = %v, want the unmutated run to fail", err)
+	}
+}
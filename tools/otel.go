@@ -0,0 +1,341 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// otlpExport is an OTLP/JSON ExportTraceServiceRequest, as written by the
+// collector's file exporter.
+type otlpExport struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+		// Name used before OTLP 0.15.
+		InstrumentationLibrarySpans []struct {
+			Spans []otlpSpan `json:"spans"`
+		} `json:"instrumentationLibrarySpans"`
+	} `json:"resourceSpans"`
+}
+
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId"`
+	Name         string          `json:"name"`
+	Start        json.Number     `json:"startTimeUnixNano"`
+	End          json.Number     `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string      `json:"stringValue"`
+		IntValue    *json.Number `json:"intValue"`
+	} `json:"value"`
+}
+
+// attribute returns the value of the first of the given attributes the span has.
+func (s otlpSpan) attribute(keys ...string) string {
+	for _, key := range keys {
+		for _, a := range s.Attributes {
+			if a.Key != key {
+				continue
+			}
+			if a.Value.StringValue != nil {
+				return *a.Value.StringValue
+			}
+			if a.Value.IntValue != nil {
+				return a.Value.IntValue.String()
+			}
+		}
+	}
+	return ""
+}
+
+// duration returns the span duration in milliseconds.
+func (s otlpSpan) duration() float64 {
+	start, err1 := strconv.ParseInt(s.Start.String(), 10, 64)
+	end, err2 := strconv.ParseInt(s.End.String(), 10, 64)
+	if err1 != nil || err2 != nil || end < start {
+		return 0
+	}
+	return float64(end-start) / 1e6
+}
+
+// ReadOTLP reads the spans of an OTLP/JSON file holding one or more export
+// requests, one after the other as the collector's file exporter writes them.
+func ReadOTLP(filename string) ([]otlpSpan, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var spans []otlpSpan
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	for {
+		var export otlpExport
+		if err := dec.Decode(&export); errors.Is(err, io.EOF) {
+			return spans, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", filename, err)
+		}
+		for _, rs := range export.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+			for _, ils := range rs.InstrumentationLibrarySpans {
+				spans = append(spans, ils.Spans...)
+			}
+		}
+	}
+}
+
+// LatencyStats summarizes the durations of the spans behind a function or call, in milliseconds.
+type LatencyStats struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	Max   float64 `json:"max"`
+}
+
+func newLatencyStats(durations []float64) LatencyStats {
+	sort.Float64s(durations)
+	stats := LatencyStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+	var sum float64
+	for _, d := range durations {
+		sum += d
+	}
+	percentile := func(p float64) float64 {
+		return durations[int(math.Ceil(p*float64(len(durations))))-1]
+	}
+	stats.Mean = sum / float64(len(durations))
+	stats.P50 = percentile(0.5)
+	stats.P95 = percentile(0.95)
+	stats.Max = durations[len(durations)-1]
+	return stats
+}
+
+// SpanEdge is a call between two functions derived from parent and child spans.
+type SpanEdge struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Static  bool         `json:"static"` // Whether the static call graph has the call.
+	Latency LatencyStats `json:"latency"`
+}
+
+// SpanGraph is the runtime call graph derived from a set of spans.
+type SpanGraph struct {
+	Functions map[string]LatencyStats `json:"functions"`
+	Edges     []SpanEdge              `json:"edges"`
+	Spans     int                     `json:"spans"`
+	Unmapped  map[string]int          `json:"unmapped"` // Span names that match no function.
+}
+
+// spanMapper maps spans to call graph nodes.
+type spanMapper struct {
+	graph  *CallGraph
+	byFile map[string][]*FunctionInfo
+}
+
+func newSpanMapper(graph *CallGraph) *spanMapper {
+	m := &spanMapper{graph: graph, byFile: make(map[string][]*FunctionInfo)}
+	for _, node := range graph.Nodes {
+		if node.Info != nil {
+			file := filepath.ToSlash(node.Info.RelativeFilePath)
+			m.byFile[file] = append(m.byFile[file], node.Info)
+		}
+	}
+	return m
+}
+
+// node returns the call graph name of the function a span records, trying the
+// code.filepath and code.lineno attributes, then code.namespace and
+// code.function, then the span name.
+func (m *spanMapper) node(s otlpSpan) string {
+	file := filepath.ToSlash(s.attribute("code.filepath", "code.file.path"))
+	line, _ := strconv.Atoi(s.attribute("code.lineno", "code.line.number"))
+	if file != "" && line > 0 {
+		for _, fi := range m.byFile[longestPathSuffix(file, sortedKeys(m.byFile))] {
+			if line >= fi.LineNumberStart && line <= fi.LineNumberEnd {
+				return getFunctionFullName(*fi)
+			}
+		}
+	}
+
+	candidates := []string{s.Name}
+	if function := s.attribute("code.function", "code.function.name"); function != "" {
+		if namespace := s.attribute("code.namespace"); namespace != "" {
+			function = namespace + "." + function
+		}
+		candidates = append([]string{function}, candidates...)
+	}
+	for _, candidate := range candidates {
+		for _, name := range []string{candidate, symbolNodeName(candidate)} {
+			if node, ok := m.graph.Nodes[name]; ok && node.Info != nil {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// BuildSpanGraph maps the spans onto the functions of the call graph and
+// derives the runtime calls from parent and child spans. Spans that map to no
+// function are skipped, linking their children to the nearest mapped ancestor.
+func BuildSpanGraph(graph *CallGraph, spans []otlpSpan) *SpanGraph {
+	mapper := newSpanMapper(graph)
+	type spanKey struct{ trace, span string }
+	byID := make(map[spanKey]otlpSpan, len(spans))
+	names := make(map[spanKey]string, len(spans))
+	sg := &SpanGraph{Functions: make(map[string]LatencyStats), Spans: len(spans), Unmapped: make(map[string]int)}
+
+	functionDurations := make(map[string][]float64)
+	for _, s := range spans {
+		key := spanKey{s.TraceID, s.SpanID}
+		byID[key] = s
+		if name := mapper.node(s); name != "" {
+			names[key] = name
+			functionDurations[name] = append(functionDurations[name], s.duration())
+		} else {
+			sg.Unmapped[s.Name]++
+		}
+	}
+
+	edgeDurations := make(map[Edge][]float64)
+	for _, s := range spans {
+		key := spanKey{s.TraceID, s.SpanID}
+		to, ok := names[key]
+		if !ok {
+			continue
+		}
+		for parent := s.ParentSpanID; parent != ""; {
+			pk := spanKey{s.TraceID, parent}
+			if from, ok := names[pk]; ok {
+				if from != to {
+					e := Edge{From: from, To: to}
+					edgeDurations[e] = append(edgeDurations[e], s.duration())
+				}
+				break
+			}
+			ps, ok := byID[pk]
+			if !ok {
+				break
+			}
+			parent = ps.ParentSpanID
+		}
+	}
+
+	for name, durations := range functionDurations {
+		sg.Functions[name] = newLatencyStats(durations)
+	}
+	for e, durations := range edgeDurations {
+		_, static := graph.Nodes[e.From].Calls[e.To]
+		sg.Edges = append(sg.Edges, SpanEdge{From: e.From, To: e.To, Static: static, Latency: newLatencyStats(durations)})
+	}
+	sort.Slice(sg.Edges, func(i, j int) bool {
+		if sg.Edges[i].From != sg.Edges[j].From {
+			return sg.Edges[i].From < sg.Edges[j].From
+		}
+		return sg.Edges[i].To < sg.Edges[j].To
+	})
+	return sg
+}
+
+// Overlay tags the span calls on the static call graph, adding those it
+// misses, and labels them with their latency.
+func (sg *SpanGraph) Overlay(graph *CallGraph) {
+	edges := make([]Edge, 0, len(sg.Edges))
+	for _, e := range sg.Edges {
+		edges = append(edges, Edge{From: e.From, To: e.To})
+	}
+	MergeObservedEdges(graph, edges)
+	for _, e := range sg.Edges {
+		from := graph.Nodes[e.From]
+		if from.CallLabels == nil {
+			from.CallLabels = make(map[string]string)
+		}
+		from.CallLabels[e.To] = fmt.Sprintf("%d× p50 %.1fms p95 %.1fms", e.Latency.Count, e.Latency.P50, e.Latency.P95)
+	}
+}
+
+// Report formats the span graph as text.
+func (sg *SpanGraph) Report() (string, error) {
+	var buf strings.Builder
+	mapped := 0
+	for _, stats := range sg.Functions {
+		mapped += stats.Count
+	}
+	fmt.Fprintf(&buf, "%d spans, %d mapped to %d functions\n", sg.Spans, mapped, len(sg.Functions))
+
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CALLER\tCALLEE\tCOUNT\tMEAN\tP50\tP95\tMAX\tSTATIC")
+	for _, e := range sg.Edges {
+		l := e.Latency
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.1fms\t%.1fms\t%.1fms\t%.1fms\t%t\n", e.From, e.To, l.Count, l.Mean, l.P50, l.P95, l.Max, e.Static)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	if len(sg.Unmapped) > 0 {
+		buf.WriteString("Unmapped spans:\n")
+		for _, name := range sortedKeys(sg.Unmapped) {
+			fmt.Fprintf(&buf, "  %s (%d)\n", name, sg.Unmapped[name])
+		}
+	}
+	return buf.String(), nil
+}
+
+// OtelOptions configures an Otel run.
+type OtelOptions struct {
+	Src    string // Path to the project.
+	Format string // Output format: text, json or dot.
+	Output string // Output file, stdout when empty.
+}
+
+// Otel maps the spans of an OTLP/JSON trace file onto the call graph of the
+// project and reports the latency of every runtime call.
+func Otel(traces string, opts OtelOptions) error {
+	spans, err := ReadOTLP(traces)
+	if err != nil {
+		return err
+	}
+	p, err := LoadProject(opts.Src)
+	if err != nil {
+		return err
+	}
+	sg := BuildSpanGraph(p.Graph, spans)
+	switch opts.Format {
+	case "", "text":
+		report, err := sg.Report()
+		if err != nil {
+			return err
+		}
+		return writeOutput(opts.Output, []byte(report))
+	case "json":
+		return writeJSON(opts.Output, sg)
+	case "dot":
+		sg.Overlay(p.Graph)
+		return GenerateDOT(p.Graph, opts.Output)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
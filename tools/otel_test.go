@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestReadOTLP tests the ReadOTLP function.
+func TestReadOTLP(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "traces.json")
+	data := `{"resourceSpans":[{"scopeSpans":[{"spans":[
+	{"traceId":"t1","spanId":"s1","name":"GET /","startTimeUnixNano":"1000000","endTimeUnixNano":"5000000",
+	 "attributes":[{"key":"code.filepath","value":{"stringValue":"/src/app/main.go"}},{"key":"code.lineno","value":{"intValue":"4"}}]}
+]}]}]}
+{"resourceSpans":[{"instrumentationLibrarySpans":[{"spans":[
+	{"traceId":"t1","spanId":"s2","parentSpanId":"s1","name":"db","startTimeUnixNano":1000000,"endTimeUnixNano":4000000,
+	 "attributes":[{"key":"code.lineno","value":{"intValue":7}}]}
+]}]}]}
+`
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spans, err := ReadOTLP(filename)
+	if err != nil {
+		t.Fatalf("ReadOTLP() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		parent   string
+		file     string
+		line     string
+		duration float64
+	}{
+		{name: "GET /", file: "/src/app/main.go", line: "4", duration: 4},
+		{name: "db", parent: "s1", line: "7", duration: 3},
+	}
+	if len(spans) != len(tests) {
+		t.Fatalf("ReadOTLP() returned %d spans, want %d", len(spans), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := spans[i]
+			if s.Name != tt.name || s.ParentSpanID != tt.parent {
+				t.Errorf("span = %s with parent %q, want %s with parent %q", s.Name, s.ParentSpanID, tt.name, tt.parent)
+			}
+			if file, line := s.attribute("code.filepath"), s.attribute("code.lineno"); file != tt.file || line != tt.line {
+				t.Errorf("attributes = %q:%q, want %q:%q", file, line, tt.file, tt.line)
+			}
+			if d := s.duration(); d != tt.duration {
+				t.Errorf("duration() = %v, want %v", d, tt.duration)
+			}
+		})
+	}
+}
+
+// TestBuildSpanGraph tests the BuildSpanGraph function.
+func TestBuildSpanGraph(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"main.go": `package main
+
+func handle() {
+	load()
+}
+
+func load() {}
+`,
+		"cmd/a/main.go": `package main
+
+func serve() {
+	println("a")
+}
+`,
+	})
+	span := func(id, parent, name string, start, end json.Number, attributes ...otlpAttribute) otlpSpan {
+		return otlpSpan{
+			TraceID: "t1", SpanID: id, ParentSpanID: parent, Name: name,
+			Start: start, End: end, Attributes: attributes,
+		}
+	}
+	attribute := func(key, value string) otlpAttribute {
+		a := otlpAttribute{Key: key}
+		a.Value.StringValue = &value
+		return a
+	}
+	spans := []otlpSpan{
+		span("s1", "", "GET /", "0", "5000000", attribute("code.filepath", "/src/app/main.go"), attribute("code.lineno", "4")),
+		// Unmapped spans between two mapped ones are skipped.
+		span("s2", "s1", "db", "1000000", "4000000"),
+		span("s3", "s2", "query", "1000000", "3000000", attribute("code.function", "load")),
+		span("s4", "", "GET /a", "0", "1000000", attribute("code.filepath", "/src/app/cmd/a/main.go"), attribute("code.lineno", "4")),
+	}
+	sg := BuildSpanGraph(p.Graph, spans)
+
+	wantEdges := []SpanEdge{{From: "handle", To: "load", Static: true, Latency: LatencyStats{Count: 1, Mean: 2, P50: 2, P95: 2, Max: 2}}}
+	if !reflect.DeepEqual(sg.Edges, wantEdges) {
+		t.Errorf("Edges = %+v, want %+v", sg.Edges, wantEdges)
+	}
+	tests := []struct {
+		function string
+		max      float64
+	}{
+		{function: "handle", max: 5},
+		{function: "load", max: 2},
+		{function: "serve", max: 1},
+	}
+	if len(sg.Functions) != len(tests) {
+		t.Errorf("Functions = %v, want %d functions", sg.Functions, len(tests))
+	}
+	for _, tt := range tests {
+		if stats, ok := sg.Functions[tt.function]; !ok || stats.Count != 1 || stats.Max != tt.max {
+			t.Errorf("Functions[%s] = %+v, want one span of %vms", tt.function, stats, tt.max)
+		}
+	}
+	if want := map[string]int{"db": 1}; !reflect.DeepEqual(sg.Unmapped, want) {
+		t.Errorf("Unmapped = %v, want %v", sg.Unmapped, want)
+	}
+}
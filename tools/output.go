@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// writeOutput writes data to filename, or to stdout when filename is empty.
+func writeOutput(filename string, data []byte) error {
+	if filename == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// writeJSON writes v as indented JSON to filename, or to stdout when filename is empty.
+func writeJSON(filename string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(filename, append(data, '\n'))
+}
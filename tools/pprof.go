@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// pprofProfile is the part of a pprof profile.proto message the overlay needs.
+type pprofProfile struct {
+	SampleTypes       []string // Type of each sample value, e.g. "cpu" or "alloc_space".
+	DefaultSampleType string
+	Samples           []pprofSample
+}
+
+// pprofSample is a stack of function names, innermost first, and its values.
+type pprofSample struct {
+	Stack  []string
+	Values []int64
+}
+
+// ReadPprof reads a pprof profile, gzip compressed or not.
+func ReadPprof(filename string) (*pprofProfile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	profile, err := decodePprof(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode profile %s: %w", filename, err)
+	}
+	return profile, nil
+}
+
+// protoField is a field of a protocol buffer message: a varint or fixed size
+// number in num, or the bytes of a length delimited field in data.
+type protoField struct {
+	number int
+	wire   int
+	num    uint64
+	data   []byte
+}
+
+var errTruncated = errors.New("truncated protocol buffer")
+
+// protoFields splits an encoded protocol buffer message into its fields.
+func protoFields(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		b = b[n:]
+		f := protoField{number: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case 0:
+			if f.num, n = binary.Uvarint(b); n <= 0 {
+				return nil, errTruncated
+			}
+			b = b[n:]
+		case 1:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			f.num, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return nil, errTruncated
+			}
+			f.data, b = b[n:n+int(length)], b[n+int(length):]
+		case 5:
+			if len(b) < 4 {
+				return nil, errTruncated
+			}
+			f.num, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", f.wire)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// varints returns the values of a repeated integer field, packed or not.
+func (f protoField) varints() ([]uint64, error) {
+	if f.wire != 2 {
+		return []uint64{f.num}, nil
+	}
+	var values []uint64
+	for b := f.data; len(b) > 0; {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		values = append(values, v)
+		b = b[n:]
+	}
+	return values, nil
+}
+
+// decodePprof decodes the samples, locations, functions and string table of a
+// profile.proto message and resolves every sample to function names.
+func decodePprof(data []byte) (*pprofProfile, error) {
+	fields, err := protoFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var strs []string
+	var sampleTypes [][]protoField
+	var samples [][]protoField
+	locations := make(map[uint64][]uint64) // Location ID -> function IDs, innermost first.
+	functions := make(map[uint64]uint64)   // Function ID -> name string index.
+	var defaultSampleType uint64
+	for _, f := range fields {
+		switch f.number {
+		case 1, 2, 4, 5:
+			sub, err := protoFields(f.data)
+			if err != nil {
+				return nil, err
+			}
+			switch f.number {
+			case 1: // ValueType sample_type
+				sampleTypes = append(sampleTypes, sub)
+			case 2: // Sample sample
+				samples = append(samples, sub)
+			case 4: // Location location
+				var id uint64
+				var funcs []uint64
+				for _, lf := range sub {
+					switch lf.number {
+					case 1:
+						id = lf.num
+					case 4: // Line line
+						line, err := protoFields(lf.data)
+						if err != nil {
+							return nil, err
+						}
+						for _, l := range line {
+							if l.number == 1 {
+								funcs = append(funcs, l.num)
+							}
+						}
+					}
+				}
+				locations[id] = funcs
+			case 5: // Function function
+				var id, name uint64
+				for _, ff := range sub {
+					switch ff.number {
+					case 1:
+						id = ff.num
+					case 2:
+						name = ff.num
+					}
+				}
+				functions[id] = name
+			}
+		case 6: // string string_table
+			strs = append(strs, string(f.data))
+		case 14: // int64 default_sample_type
+			defaultSampleType = f.num
+		}
+	}
+
+	str := func(i uint64) string {
+		if i < uint64(len(strs)) {
+			return strs[i]
+		}
+		return ""
+	}
+	profile := &pprofProfile{DefaultSampleType: str(defaultSampleType)}
+	if defaultSampleType == 0 {
+		profile.DefaultSampleType = ""
+	}
+	for _, st := range sampleTypes {
+		for _, f := range st {
+			if f.number == 1 {
+				profile.SampleTypes = append(profile.SampleTypes, str(f.num))
+			}
+		}
+	}
+	for _, s := range samples {
+		var sample pprofSample
+		for _, f := range s {
+			values, err := f.varints()
+			if err != nil {
+				return nil, err
+			}
+			switch f.number {
+			case 1: // uint64 location_id
+				for _, loc := range values {
+					for _, fn := range locations[loc] {
+						sample.Stack = append(sample.Stack, str(functions[fn]))
+					}
+				}
+			case 2: // int64 value
+				for _, v := range values {
+					sample.Values = append(sample.Values, int64(v))
+				}
+			}
+		}
+		profile.Samples = append(profile.Samples, sample)
+	}
+	return profile, nil
+}
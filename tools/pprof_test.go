@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// protoVarint encodes a varint field of a protocol buffer message.
+func protoVarint(number int, v uint64) []byte {
+	b := binary.AppendUvarint(nil, uint64(number)<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+// protoBytes encodes a length delimited field of a protocol buffer message.
+func protoBytes(number int, data ...[]byte) []byte {
+	joined := bytes.Join(data, nil)
+	b := binary.AppendUvarint(nil, uint64(number)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(joined)))
+	return append(b, joined...)
+}
+
+// protoPacked encodes a packed repeated integer field.
+func protoPacked(number int, values ...uint64) []byte {
+	var data []byte
+	for _, v := range values {
+		data = binary.AppendUvarint(data, v)
+	}
+	return protoBytes(number, data)
+}
+
+// writeTestPprof writes a gzipped CPU profile of main -> run -> load, where
+// load also runs inlined into run and through a runtime frame.
+func writeTestPprof(t *testing.T) string {
+	t.Helper()
+	strs := []string{"", "samples", "count", "cpu", "nanoseconds", "main.main", "main.run", "main.load", "runtime.mallocgc"}
+	var msg []byte
+	for _, s := range strs {
+		msg = append(msg, protoBytes(6, []byte(s))...)
+	}
+	msg = append(msg, protoBytes(1, protoVarint(1, 1), protoVarint(2, 2))...)
+	msg = append(msg, protoBytes(1, protoVarint(1, 3), protoVarint(2, 4))...)
+	for id := uint64(1); id <= 4; id++ {
+		msg = append(msg, protoBytes(5, protoVarint(1, id), protoVarint(2, id+4))...)
+		msg = append(msg, protoBytes(4, protoVarint(1, id), protoBytes(4, protoVarint(1, id)))...)
+	}
+	// Location 5 holds load inlined into run, innermost first.
+	msg = append(msg, protoBytes(4, protoVarint(1, 5), protoBytes(4, protoVarint(1, 3)), protoBytes(4, protoVarint(1, 2)))...)
+	msg = append(msg, protoBytes(2, protoPacked(1, 4, 3, 2, 1), protoPacked(2, 1, 10))...)
+	// Unpacked repeated fields.
+	msg = append(msg, protoBytes(2, protoVarint(1, 2), protoVarint(1, 1), protoVarint(2, 1), protoVarint(2, 5))...)
+	msg = append(msg, protoBytes(2, protoPacked(1, 5, 1), protoPacked(2, 1, 20))...)
+	msg = append(msg, protoVarint(14, 3)...)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "cpu.pprof")
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// TestReadPprof tests the ReadPprof function.
+func TestReadPprof(t *testing.T) {
+	profile, err := ReadPprof(writeTestPprof(t))
+	if err != nil {
+		t.Fatalf("ReadPprof() error = %v", err)
+	}
+	want := &pprofProfile{
+		SampleTypes:       []string{"samples", "cpu"},
+		DefaultSampleType: "cpu",
+		Samples: []pprofSample{
+			{Stack: []string{"runtime.mallocgc", "main.load", "main.run", "main.main"}, Values: []int64{1, 10}},
+			{Stack: []string{"main.run", "main.main"}, Values: []int64{1, 5}},
+			{Stack: []string{"main.load", "main.run", "main.main"}, Values: []int64{1, 20}},
+		},
+	}
+	if !reflect.DeepEqual(profile, want) {
+		t.Errorf("ReadPprof() = %+v, want %+v", profile, want)
+	}
+
+	if _, err := decodePprof([]byte{0x12, 0x05, 0x08}); err == nil {
+		t.Errorf("decodePprof() of a truncated message succeeded, want an error")
+	}
+}
@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ProfileOverlay holds the runtime cost of the functions and calls of a call
+// graph, taken from a pprof profile.
+type ProfileOverlay struct {
+	SampleType string
+	Total      int64
+	Flat       map[string]int64 // Samples with the function at the top of the stack.
+	Cum        map[string]int64 // Samples with the function anywhere on the stack.
+	Edges      map[Edge]int64   // Samples with the caller directly above the callee.
+
+	graph *CallGraph
+}
+
+var (
+	typeParams     = regexp.MustCompile(`\[[^\]]*\]`)
+	closureSymbols = regexp.MustCompile(`^(func|gowrap)?\d+$`)
+)
+
+// symbolNodeName converts a Go symbol such as
+// "github.com/x/y/pkg.(*Server).Run.func1" to its call graph name, "*Server.Run".
+// Closures are attributed to the function declaring them.
+func symbolNodeName(symbol string) string {
+	s := symbol[strings.LastIndex(symbol, "/")+1:]
+	if i := strings.Index(s, "."); i >= 0 {
+		s = s[i+1:]
+	}
+	s = typeParams.ReplaceAllString(s, "")
+	if strings.HasPrefix(s, "(*") {
+		s = "*" + strings.Replace(s[2:], ")", "", 1)
+	}
+	parts := strings.Split(s, ".")
+	for len(parts) > 1 && closureSymbols.MatchString(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ".")
+}
+
+// BuildProfileOverlay weights the project functions of the graph with the
+// samples of the profile, for the given sample type or the profile's default.
+// Frames outside the project are skipped, so a call through the standard
+// library, such as a sort.Slice callback, counts as an edge between the two
+// project functions around it.
+func BuildProfileOverlay(graph *CallGraph, profile *pprofProfile, sampleType string) (*ProfileOverlay, error) {
+	if len(profile.SampleTypes) == 0 {
+		return nil, fmt.Errorf("profile has no sample types")
+	}
+	if sampleType == "" {
+		sampleType = profile.DefaultSampleType
+	}
+	index := len(profile.SampleTypes) - 1
+	if sampleType != "" {
+		index = -1
+		for i, t := range profile.SampleTypes {
+			if t == sampleType {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("sample type %s not in profile, have %s", sampleType, strings.Join(profile.SampleTypes, ", "))
+		}
+	}
+
+	overlay := &ProfileOverlay{
+		SampleType: profile.SampleTypes[index],
+		Flat:       make(map[string]int64),
+		Cum:        make(map[string]int64),
+		Edges:      make(map[Edge]int64),
+		graph:      graph,
+	}
+	for _, sample := range profile.Samples {
+		if index >= len(sample.Values) {
+			continue
+		}
+		value := sample.Values[index]
+		overlay.Total += value
+
+		var stack []string
+		for i, symbol := range sample.Stack {
+			name := symbolNodeName(symbol)
+			if node, ok := graph.Nodes[name]; !ok || node.Info == nil {
+				continue
+			}
+			if i == 0 {
+				overlay.Flat[name] += value
+			}
+			if len(stack) == 0 || stack[len(stack)-1] != name {
+				stack = append(stack, name)
+			}
+		}
+		seen := make(map[string]bool)
+		edges := make(map[Edge]bool)
+		for i, name := range stack {
+			if !seen[name] {
+				seen[name] = true
+				overlay.Cum[name] += value
+			}
+			if i+1 < len(stack) {
+				e := Edge{From: stack[i+1], To: name}
+				if !edges[e] {
+					edges[e] = true
+					overlay.Edges[e] += value
+				}
+			}
+		}
+	}
+	return overlay, nil
+}
+
+// LabelEdges labels the calls of the graph with the samples the profile saw
+// for them, so that the edge weights show in the rendered graph.
+func (o *ProfileOverlay) LabelEdges(graph *CallGraph) {
+	for e, value := range o.Edges {
+		from, ok := graph.Nodes[e.From]
+		if !ok {
+			continue
+		}
+		if _, ok := from.Calls[e.To]; !ok {
+			continue
+		}
+		if from.CallLabels == nil {
+			from.CallLabels = make(map[string]string)
+		}
+		from.CallLabels[e.To] = fmt.Sprintf("%d (%.1f%%)", value, o.percent(value))
+	}
+}
+
+// percent returns the share of the profile's total a value stands for.
+func (o *ProfileOverlay) percent(v int64) float64 {
+	if o.Total == 0 {
+		return 0
+	}
+	return 100 * float64(v) / float64(o.Total)
+}
+
+// UnobservedEdges returns the static calls of functions that ran but that the
+// profile never saw.
+func (o *ProfileOverlay) UnobservedEdges() []Edge {
+	var edges []Edge
+	for _, name := range sortedKeys(o.Cum) {
+		for _, callee := range sortedKeys(o.graph.Nodes[name].Calls) {
+			e := Edge{From: name, To: callee}
+			if o.graph.Nodes[callee].Info != nil && o.Edges[e] == 0 {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
+
+// DynamicEdges returns the calls seen in the profile that the static call graph misses.
+func (o *ProfileOverlay) DynamicEdges() []Edge {
+	var edges []Edge
+	for e := range o.Edges {
+		if _, ok := o.graph.Nodes[e.From].Calls[e.To]; !ok {
+			edges = append(edges, e)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool { return o.Edges[edges[i]] > o.Edges[edges[j]] })
+	return edges
+}
+
+// Report formats the costliest functions and the differences between the
+// static and the runtime call graph as text.
+func (o *ProfileOverlay) Report() (string, error) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Profile %s: %d total\n", o.SampleType, o.Total)
+	names := sortedKeys(o.Cum)
+	sort.SliceStable(names, func(i, j int) bool { return o.Cum[names[i]] > o.Cum[names[j]] })
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FLAT\tFLAT%\tCUM\tCUM%\tFUNCTION")
+	for _, name := range names {
+		fmt.Fprintf(w, "%d\t%.1f%%\t%d\t%.1f%%\t%s\n", o.Flat[name], o.percent(o.Flat[name]), o.Cum[name], o.percent(o.Cum[name]), name)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	unobserved := o.UnobservedEdges()
+	fmt.Fprintf(&buf, "Static calls never observed (%d):\n", len(unobserved))
+	for _, e := range unobserved {
+		fmt.Fprintf(&buf, "  %s -> %s\n", e.From, e.To)
+	}
+	dynamic := o.DynamicEdges()
+	fmt.Fprintf(&buf, "Runtime calls missing from the static graph (%d):\n", len(dynamic))
+	for _, e := range dynamic {
+		fmt.Fprintf(&buf, "  %s -> %s (%d)\n", e.From, e.To, o.Edges[e])
+	}
+	return buf.String(), nil
+}
+
+// ProfileStyle sizes function nodes by their cumulative cost.
+func ProfileStyle(o *ProfileOverlay) NodeStyle {
+	var highest int64
+	for _, v := range o.Cum {
+		highest = max(highest, v)
+	}
+	return func(node *FunctionNode) map[string]string {
+		cum, ok := o.Cum[node.Name]
+		if !ok || highest == 0 {
+			return nil
+		}
+		share := float64(cum) / float64(highest)
+		return map[string]string{
+			"fontsize": fmt.Sprintf("%.0f", 10+24*share),
+			"penwidth": fmt.Sprintf("%.1f", 1+4*share),
+			"tooltip":  fmt.Sprintf("flat %d, cum %d %s", o.Flat[node.Name], cum, o.SampleType),
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSymbolNodeName tests the symbolNodeName function.
+func TestSymbolNodeName(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   string
+	}{
+		{symbol: "main.main", want: "main"},
+		{symbol: "github.com/Seann-Moser/gpa/tools.LoadProject", want: "LoadProject"},
+		{symbol: "github.com/Seann-Moser/gpa/tools.(*Baseline).Compare", want: "*Baseline.Compare"},
+		{symbol: "github.com/x/y/pkg.Server.Run", want: "Server.Run"},
+		{symbol: "github.com/x/y/pkg.(*Server).Run.func1", want: "*Server.Run"},
+		{symbol: "github.com/x/y/pkg.Walk.func2.1", want: "Walk"},
+		{symbol: "github.com/x/y/pkg.Map[...]", want: "Map"},
+		{symbol: "github.com/x/y/pkg.(*List[...]).Push", want: "*List.Push"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			if got := symbolNodeName(tt.symbol); got != tt.want {
+				t.Errorf("symbolNodeName(%q) = %q, want %q", tt.symbol, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildProfileOverlay tests the BuildProfileOverlay function.
+func TestBuildProfileOverlay(t *testing.T) {
+	profile, err := ReadPprof(writeTestPprof(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sampleType string
+		total      int64
+		flat       map[string]int64
+		cum        map[string]int64
+		edges      map[Edge]int64
+		labels     map[Edge]string
+	}{
+		{
+			// The runtime frame above load is skipped, so its sample has no flat function.
+			sampleType: "",
+			total:      35,
+			flat:       map[string]int64{"load": 20, "run": 5},
+			cum:        map[string]int64{"main": 35, "run": 35, "load": 30},
+			edges:      map[Edge]int64{{From: "main", To: "run"}: 35, {From: "run", To: "load"}: 30},
+			labels:     map[Edge]string{{From: "main", To: "run"}: "35 (100.0%)", {From: "run", To: "load"}: "30 (85.7%)"},
+		},
+		{
+			sampleType: "samples",
+			total:      3,
+			flat:       map[string]int64{"load": 1, "run": 1},
+			cum:        map[string]int64{"main": 3, "run": 3, "load": 2},
+			edges:      map[Edge]int64{{From: "main", To: "run"}: 3, {From: "run", To: "load"}: 2},
+			labels:     map[Edge]string{{From: "main", To: "run"}: "3 (100.0%)", {From: "run", To: "load"}: "2 (66.7%)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("sample type "+tt.sampleType, func(t *testing.T) {
+			graph := newTestGraph(Edge{"main", "run"}, Edge{"run", "load"}, Edge{"main", "report"})
+			overlay, err := BuildProfileOverlay(graph, profile, tt.sampleType)
+			if err != nil {
+				t.Fatalf("BuildProfileOverlay() error = %v", err)
+			}
+			if overlay.Total != tt.total {
+				t.Errorf("Total = %d, want %d", overlay.Total, tt.total)
+			}
+			if !reflect.DeepEqual(overlay.Flat, tt.flat) {
+				t.Errorf("Flat = %v, want %v", overlay.Flat, tt.flat)
+			}
+			if !reflect.DeepEqual(overlay.Cum, tt.cum) {
+				t.Errorf("Cum = %v, want %v", overlay.Cum, tt.cum)
+			}
+			if !reflect.DeepEqual(overlay.Edges, tt.edges) {
+				t.Errorf("Edges = %v, want %v", overlay.Edges, tt.edges)
+			}
+			if want := []Edge{{From: "main", To: "report"}}; !reflect.DeepEqual(overlay.UnobservedEdges(), want) {
+				t.Errorf("UnobservedEdges() = %v, want %v", overlay.UnobservedEdges(), want)
+			}
+
+			overlay.LabelEdges(graph)
+			labels := make(map[Edge]string)
+			for _, node := range graph.Nodes {
+				for callee, label := range node.CallLabels {
+					labels[Edge{From: node.Name, To: callee}] = label
+				}
+			}
+			if !reflect.DeepEqual(labels, tt.labels) {
+				t.Errorf("edge labels = %v, want %v", labels, tt.labels)
+			}
+		})
+	}
+
+	if _, err := BuildProfileOverlay(newTestGraph(), profile, "alloc_space"); err == nil {
+		t.Errorf("BuildProfileOverlay() with a missing sample type succeeded, want an error")
+	}
+}
@@ -13,27 +13,34 @@ import (
 )
 
 type FunctionInfo struct {
-	RelativeFilePath string
-	PkgName          string
-	Name             string
-	StructName       string
-	Parameters       []ParameterInfo
-	Returns          []ReturnInfo
-	LineNumberStart  int
-	LineNumberEnd    int
+	RelativeFilePath string          `json:"file"`
+	PkgName          string          `json:"package"`
+	Name             string          `json:"name"`
+	StructName       string          `json:"struct,omitempty"`
+	Parameters       []ParameterInfo `json:"parameters,omitempty"`
+	Returns          []ReturnInfo    `json:"returns,omitempty"`
+	LineNumberStart  int             `json:"lineStart"`
+	LineNumberEnd    int             `json:"lineEnd"`
+
+	LinesOfCode          int `json:"linesOfCode"`          // Lines holding code, without blank and comment lines.
+	CyclomaticComplexity int `json:"cyclomaticComplexity"` // One plus the number of decision points.
+	CognitiveComplexity  int `json:"cognitiveComplexity"`  // Decision points weighted by nesting.
+	MaxNesting           int `json:"maxNesting"`           // Deepest nesting of control structures and closures.
+	ReturnCount          int `json:"returnCount"`          // Number of return statements.
+	ParameterCount       int `json:"parameterCount"`       // Number of parameters.
 }
 
 type ParameterInfo struct {
-	Name       string
-	Type       string
-	ImportPath string
-	ImportName string
+	Name       string `json:"name,omitempty"`
+	Type       string `json:"type"`
+	ImportPath string `json:"importPath,omitempty"`
+	ImportName string `json:"importName,omitempty"`
 }
 
 type ReturnInfo struct {
-	Type       string
-	ImportPath string
-	ImportName string
+	Type       string `json:"type"`
+	ImportPath string `json:"importPath,omitempty"`
+	ImportName string `json:"importName,omitempty"`
 }
 
 func GetFunctionWithComments(fi FunctionInfo, projectRoot string) (string, error) {
@@ -115,7 +122,7 @@ func GetFunctions(projectRoot string) ([]FunctionInfo, error) {
 	if projectRoot == "" {
 		projectRoot = cwd
 	}
-	fmt.Printf("Parsing project root %s\n", projectRoot)
+	fmt.Fprintf(os.Stderr, "Parsing project root %s\n", projectRoot)
 	var functions []FunctionInfo
 
 	err = filepath.Walk(projectRoot, func(path string, info os.FileInfo, err error) error {
@@ -136,7 +143,7 @@ func GetFunctions(projectRoot string) ([]FunctionInfo, error) {
 		}
 		funcs, err := parseFile(relPath, path)
 		if err != nil {
-			fmt.Printf("Error parsing file %s: %v\n", path, err)
+			fmt.Fprintf(os.Stderr, "Error parsing file %s: %v\n", path, err)
 		} else {
 			functions = append(functions, funcs...)
 		}
@@ -160,6 +167,8 @@ func PrintFunctions(functions ...FunctionInfo) {
 			fmt.Printf("Function: %s\n", fi.Name)
 		}
 		fmt.Printf("Function Lines: %d-%d\n", fi.LineNumberStart, fi.LineNumberEnd)
+		fmt.Printf("Complexity: LOC %d, cyclomatic %d, cognitive %d, nesting %d, returns %d, parameters %d\n",
+			fi.LinesOfCode, fi.CyclomaticComplexity, fi.CognitiveComplexity, fi.MaxNesting, fi.ReturnCount, fi.ParameterCount)
 		fmt.Println("Parameters:")
 		for _, param := range fi.Parameters {
 			fmt.Printf("  Name: %s, Type: %s, ImportName: %s, ImportPath: %s\n",
@@ -175,8 +184,12 @@ func PrintFunctions(functions ...FunctionInfo) {
 }
 
 func parseFile(relPath, fullPath string) ([]FunctionInfo, error) {
+	src, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
 	fset := token.NewFileSet()
-	fileAst, err := parser.ParseFile(fset, fullPath, nil, parser.ParseComments)
+	fileAst, err := parser.ParseFile(fset, fullPath, src, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
@@ -264,6 +277,8 @@ func parseFile(relPath, fullPath string) ([]FunctionInfo, error) {
 		fi.LineNumberStart = start.Line
 		fi.LineNumberEnd = end.Line
 
+		setComplexity(&fi, funcDecl, fset, src)
+
 		functions = append(functions, fi)
 	}
 
@@ -299,3 +314,38 @@ func getImportInfo(expr ast.Expr, imports map[string]string) (importName, import
 
 	return "", ""
 }
+
+// Project bundles the parsed functions and call graph of a source tree so that
+// checks and reports can share a single analysis pass.
+type Project struct {
+	Root      string
+	Functions []FunctionInfo
+	Graph     *CallGraph
+
+	snapshot bool                       // Loaded from a graph snapshot, whose sources may be gone or changed.
+	methods  map[string][]*FunctionInfo // Methods by name, built on first use.
+}
+
+// LoadProject parses every function under projectRoot and builds its call
+// graph. A projectRoot naming a file is read as a graph snapshot instead.
+func LoadProject(projectRoot string) (*Project, error) {
+	if projectRoot == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		projectRoot = cwd
+	}
+	if info, err := os.Stat(projectRoot); err == nil && info.Mode().IsRegular() {
+		return LoadGraph(projectRoot)
+	}
+	functions, err := GetFunctions(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := BuildCallGraph(functions, projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &Project{Root: projectRoot, Functions: functions, Graph: graph}, nil
+}
@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadTestProject writes the files, keyed by their slash-separated path, to a
+// temporary directory and loads it as a project.
+func loadTestProject(t *testing.T, files map[string]string) *Project {
+	t.Helper()
+	root := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := LoadProject(root)
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	return p
+}
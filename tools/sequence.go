@@ -0,0 +1,479 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// Kinds of sequence diagram steps.
+const (
+	StepCall = "call" // A message from one participant to another.
+	StepLoop = "loop" // Steps repeated by a for or range statement.
+	StepOpt  = "opt"  // Steps run only when an if statement without else holds.
+	StepAlt  = "alt"  // Branches of an if, switch or select statement.
+)
+
+// SequenceStep is a message or a block of a sequence diagram.
+type SequenceStep struct {
+	Kind     string           `json:"kind"`
+	From     string           `json:"from,omitempty"` // Participant IDs of a call.
+	To       string           `json:"to,omitempty"`
+	Label    string           `json:"label"`
+	Async    bool             `json:"async,omitempty"`    // Whether the call is run by a go statement.
+	Deferred bool             `json:"deferred,omitempty"` // Whether the call is run by a defer statement.
+	Steps    []SequenceStep   `json:"steps,omitempty"`    // Calls the callee makes, or the body of a loop or opt.
+	Branches []SequenceBranch `json:"branches,omitempty"` // Branches of an alt.
+}
+
+// SequenceBranch is a branch of an alt block.
+type SequenceBranch struct {
+	Label string         `json:"label,omitempty"` // Empty for an else.
+	Steps []SequenceStep `json:"steps"`
+}
+
+// SequenceParticipant is a package or type taking part in a sequence diagram.
+type SequenceParticipant struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// SequenceDiagram is the ordered calls made from a function.
+type SequenceDiagram struct {
+	Entry        string                `json:"entry"`
+	Participants []SequenceParticipant `json:"participants"`
+	Steps        []SequenceStep        `json:"steps"`
+}
+
+// funcSource is a function declaration and the file declaring it.
+type funcSource struct {
+	sf *sourceFile
+	fd *ast.FuncDecl
+}
+
+// sequenceBuilder walks function bodies in source order to build a sequence diagram.
+type sequenceBuilder struct {
+	p        *Project
+	decls    map[string]funcSource
+	diagram  *SequenceDiagram
+	seen     map[string]bool // Participant IDs already declared.
+	expanded map[string]bool // Functions on the current call stack.
+}
+
+// sequenceScope is the function whose body is being walked.
+type sequenceScope struct {
+	sf       *sourceFile
+	from     string // Participant ID of the function.
+	depth    int    // Call levels left to expand, including this one.
+	deferred []SequenceStep
+}
+
+// BuildSequence builds the sequence diagram of the calls made from a project
+// function, expanding the calls to other project functions depth levels deep.
+func BuildSequence(p *Project, entry string, depth int) (*SequenceDiagram, error) {
+	node, err := FindNode(p.Graph, entry)
+	if err != nil {
+		return nil, err
+	}
+	if node.Info == nil {
+		return nil, fmt.Errorf("function %s is not declared in the project", node.Name)
+	}
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+	b := &sequenceBuilder{
+		p:        p,
+		decls:    make(map[string]funcSource),
+		diagram:  &SequenceDiagram{Entry: node.Name},
+		seen:     make(map[string]bool),
+		expanded: make(map[string]bool),
+	}
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			if name := funcDeclFullName(fd); b.decls[name].fd == nil {
+				b.decls[name] = funcSource{sf: sf, fd: fd}
+			}
+		}
+	}
+	b.diagram.Steps = b.function(node.Name, b.participant(node.Info), max(depth, 1))
+	return b.diagram, nil
+}
+
+// participant returns the ID of the participant of a project function: its
+// type for methods, its package otherwise.
+func (b *sequenceBuilder) participant(fi *FunctionInfo) string {
+	if fi.StructName != "" {
+		typeName := strings.TrimPrefix(fi.StructName, "*")
+		return b.declare(packageKey(fi)+"."+typeName, typeName)
+	}
+	return b.declare(packageKey(fi), packageKey(fi))
+}
+
+// declare adds a participant the first time it takes part in the diagram.
+func (b *sequenceBuilder) declare(key, label string) string {
+	id := sanitizeIdentifier(key)
+	if !b.seen[id] {
+		b.seen[id] = true
+		b.diagram.Participants = append(b.diagram.Participants, SequenceParticipant{ID: id, Label: label})
+	}
+	return id
+}
+
+// function returns the steps of the body of a project function, deferred calls last.
+func (b *sequenceBuilder) function(name, from string, depth int) []SequenceStep {
+	src, ok := b.decls[name]
+	if !ok {
+		return nil
+	}
+	b.expanded[name] = true
+	defer delete(b.expanded, name)
+	s := &sequenceScope{sf: src.sf, from: from, depth: depth}
+	steps := b.stmts(s, src.fd.Body.List)
+	for i := len(s.deferred) - 1; i >= 0; i-- {
+		steps = append(steps, s.deferred[i])
+	}
+	return steps
+}
+
+func (b *sequenceBuilder) stmts(s *sequenceScope, list []ast.Stmt) []SequenceStep {
+	var steps []SequenceStep
+	for _, stmt := range list {
+		steps = append(steps, b.stmt(s, stmt)...)
+	}
+	return steps
+}
+
+func (b *sequenceBuilder) stmt(s *sequenceScope, stmt ast.Stmt) []SequenceStep {
+	switch st := stmt.(type) {
+	case *ast.BlockStmt:
+		return b.stmts(s, st.List)
+	case *ast.LabeledStmt:
+		return b.stmt(s, st.Stmt)
+	case *ast.IfStmt:
+		steps := b.exprCalls(s, st.Init)
+		steps = append(steps, b.exprCalls(s, st.Cond)...)
+		var branches []SequenceBranch
+		var pre []SequenceStep
+		label := "if " + s.sf.text(st.Cond)
+		for cur := st; ; {
+			branches = append(branches, SequenceBranch{Label: label, Steps: append(pre, b.stmts(s, cur.Body.List)...)})
+			next, ok := cur.Else.(*ast.IfStmt)
+			if !ok {
+				if cur.Else != nil {
+					branches = append(branches, SequenceBranch{Steps: b.stmt(s, cur.Else)})
+				}
+				break
+			}
+			// Calls in the condition of an else if run once the branches before it are skipped.
+			cur = next
+			label = "else if " + s.sf.text(cur.Cond)
+			pre = append(b.exprCalls(s, cur.Init), b.exprCalls(s, cur.Cond)...)
+		}
+		return append(steps, branchBlock(branches)...)
+	case *ast.ForStmt:
+		steps := b.exprCalls(s, st.Init)
+		label := "for"
+		if st.Cond != nil {
+			label += " " + s.sf.text(st.Cond)
+		}
+		body := b.exprCalls(s, st.Cond)
+		body = append(body, b.stmts(s, st.Body.List)...)
+		body = append(body, b.exprCalls(s, st.Post)...)
+		if len(body) == 0 {
+			return steps
+		}
+		return append(steps, SequenceStep{Kind: StepLoop, Label: label, Steps: body})
+	case *ast.RangeStmt:
+		steps := b.exprCalls(s, st.X)
+		body := b.stmts(s, st.Body.List)
+		if len(body) == 0 {
+			return steps
+		}
+		return append(steps, SequenceStep{Kind: StepLoop, Label: "range " + s.sf.text(st.X), Steps: body})
+	case *ast.SwitchStmt:
+		steps := b.exprCalls(s, st.Init)
+		steps = append(steps, b.exprCalls(s, st.Tag)...)
+		var branches []SequenceBranch
+		for _, clause := range st.Body.List {
+			cc := clause.(*ast.CaseClause)
+			var body []SequenceStep
+			var labels []string
+			for _, expr := range cc.List {
+				body = append(body, b.exprCalls(s, expr)...)
+				labels = append(labels, s.sf.text(expr))
+			}
+			body = append(body, b.stmts(s, cc.Body)...)
+			branches = append(branches, SequenceBranch{Label: caseLabel(labels), Steps: body})
+		}
+		return append(steps, branchBlock(branches)...)
+	case *ast.TypeSwitchStmt:
+		steps := b.exprCalls(s, st.Init)
+		steps = append(steps, b.exprCalls(s, st.Assign)...)
+		var branches []SequenceBranch
+		for _, clause := range st.Body.List {
+			cc := clause.(*ast.CaseClause)
+			var labels []string
+			for _, expr := range cc.List {
+				labels = append(labels, s.sf.text(expr))
+			}
+			branches = append(branches, SequenceBranch{Label: caseLabel(labels), Steps: b.stmts(s, cc.Body)})
+		}
+		return append(steps, branchBlock(branches)...)
+	case *ast.SelectStmt:
+		var branches []SequenceBranch
+		for _, clause := range st.Body.List {
+			cc := clause.(*ast.CommClause)
+			label := "default"
+			var body []SequenceStep
+			if cc.Comm != nil {
+				label = "case " + s.sf.text(cc.Comm)
+				body = b.exprCalls(s, cc.Comm)
+			}
+			body = append(body, b.stmts(s, cc.Body)...)
+			branches = append(branches, SequenceBranch{Label: label, Steps: body})
+		}
+		return branchBlock(branches)
+	case *ast.GoStmt:
+		steps := b.argCalls(s, st.Call)
+		if step, ok := b.call(s, st.Call); ok {
+			step.Async = true
+			step.Label = "go " + step.Label
+			steps = append(steps, step)
+		}
+		return steps
+	case *ast.DeferStmt:
+		// The arguments are evaluated by the defer statement, the call when the function returns.
+		steps := b.argCalls(s, st.Call)
+		if step, ok := b.call(s, st.Call); ok {
+			step.Deferred = true
+			step.Label = "defer " + step.Label
+			s.deferred = append(s.deferred, step)
+		}
+		return steps
+	default:
+		return b.exprCalls(s, stmt)
+	}
+}
+
+// branchBlock returns an alt block of the branches, an opt block for a single
+// branch, or nothing when no branch makes a call.
+func branchBlock(branches []SequenceBranch) []SequenceStep {
+	calls := false
+	for _, branch := range branches {
+		calls = calls || len(branch.Steps) > 0
+	}
+	switch {
+	case !calls:
+		return nil
+	case len(branches) == 1:
+		return []SequenceStep{{Kind: StepOpt, Label: branches[0].Label, Steps: branches[0].Steps}}
+	default:
+		return []SequenceStep{{Kind: StepAlt, Branches: branches}}
+	}
+}
+
+func caseLabel(exprs []string) string {
+	if len(exprs) == 0 {
+		return "default"
+	}
+	return "case " + strings.Join(exprs, ", ")
+}
+
+// exprCalls returns the calls made by a node in evaluation order, the calls in
+// the function and arguments of a call before the call itself. Function
+// literals are left out as their bodies run later, if at all.
+func (b *sequenceBuilder) exprCalls(s *sequenceScope, node ast.Node) []SequenceStep {
+	if node == nil {
+		return nil
+	}
+	var steps []SequenceStep
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			ast.Inspect(n.Fun, visit)
+			for _, arg := range n.Args {
+				ast.Inspect(arg, visit)
+			}
+			if step, ok := b.call(s, n); ok {
+				steps = append(steps, step)
+			}
+			return false
+		}
+		return true
+	}
+	ast.Inspect(node, visit)
+	return steps
+}
+
+// argCalls returns the calls made while evaluating the function and arguments of a call.
+func (b *sequenceBuilder) argCalls(s *sequenceScope, call *ast.CallExpr) []SequenceStep {
+	steps := b.exprCalls(s, call.Fun)
+	for _, arg := range call.Args {
+		steps = append(steps, b.exprCalls(s, arg)...)
+	}
+	return steps
+}
+
+// call returns the message of a call, expanding calls to project functions
+// while levels are left. Builtins and conversions to predeclared types are skipped.
+func (b *sequenceBuilder) call(s *sequenceScope, call *ast.CallExpr) (SequenceStep, bool) {
+	if _, ok := call.Fun.(*ast.FuncLit); ok {
+		return SequenceStep{Kind: StepCall, From: s.from, To: s.from, Label: "func()"}, true
+	}
+	if ident, ok := call.Fun.(*ast.Ident); ok {
+		switch types.Universe.Lookup(ident.Name).(type) {
+		case *types.Builtin, *types.TypeName:
+			return SequenceStep{}, false
+		}
+	}
+
+	if infos := b.p.qualifiedCalleeInfos(s.sf, call); len(infos) == 1 {
+		fi := infos[0]
+		name := getFunctionFullName(*fi)
+		step := SequenceStep{Kind: StepCall, From: s.from, To: b.participant(fi), Label: fi.Name + "()"}
+		if s.depth > 1 && !b.expanded[name] {
+			step.Steps = b.function(name, step.To, s.depth-1)
+		}
+		return step, true
+	}
+
+	name := s.sf.callName(call)
+	key := externalKey(name)
+	if key == "external:builtin" {
+		// A call through a function value.
+		return SequenceStep{Kind: StepCall, From: s.from, To: s.from, Label: name + "()"}, true
+	}
+	qualifier := strings.TrimPrefix(key, "external:")
+	to := b.declare(key, qualifier)
+	return SequenceStep{Kind: StepCall, From: s.from, To: to, Label: strings.TrimPrefix(name, qualifier+".") + "()"}, true
+}
+
+// text returns the source of a node on a single line.
+func (sf *sourceFile) text(node ast.Node) string {
+	start, end := sf.Fset.Position(node.Pos()).Offset, sf.Fset.Position(node.End()).Offset
+	return strings.Join(strings.Fields(string(sf.Src[start:end])), " ")
+}
+
+// sequenceSyntax is the notation of a sequence diagram language.
+type sequenceSyntax struct {
+	header, footer string
+	participant    string // Format of a participant declaration, given the ID and label.
+	sync, async    string // Arrows of calls.
+	reply          string // Arrow of the return of an expanded call.
+	escape         func(string) string
+}
+
+var sequenceSyntaxes = map[string]sequenceSyntax{
+	"mermaid": {
+		header:      "sequenceDiagram\n",
+		participant: "participant %s as %s\n",
+		sync:        "->>",
+		async:       "-)",
+		reply:       "-->>",
+		escape:      escapeStringForMermaidSequence,
+	},
+	"plantuml": {
+		header:      "@startuml\n",
+		footer:      "@enduml\n",
+		participant: "participant %[2]q as %[1]s\n",
+		sync:        "->",
+		async:       "->>",
+		reply:       "-->",
+		escape:      func(s string) string { return s },
+	},
+}
+
+// escapeStringForMermaidSequence escapes the text of a Mermaid sequence
+// diagram line, where # starts an entity code and ; ends the line.
+func escapeStringForMermaidSequence(s string) string {
+	return strings.NewReplacer("#", "#35;", ";", "#59;", "\n", " ").Replace(s)
+}
+
+// Render formats the diagram as Mermaid or PlantUML.
+func (d *SequenceDiagram) Render(format string) (string, error) {
+	syntax, ok := sequenceSyntaxes[format]
+	if !ok {
+		return "", fmt.Errorf("unknown format: %s", format)
+	}
+	var buf strings.Builder
+	buf.WriteString(syntax.header)
+	for _, participant := range d.Participants {
+		buf.WriteString("    ")
+		fmt.Fprintf(&buf, syntax.participant, participant.ID, syntax.escape(participant.Label))
+	}
+
+	var writeSteps func(steps []SequenceStep, indent string)
+	writeSteps = func(steps []SequenceStep, indent string) {
+		for _, step := range steps {
+			switch step.Kind {
+			case StepCall:
+				arrow := syntax.sync
+				if step.Async {
+					arrow = syntax.async
+				}
+				fmt.Fprintf(&buf, "%s%s%s%s: %s\n", indent, step.From, arrow, step.To, syntax.escape(step.Label))
+				if len(step.Steps) > 0 {
+					writeSteps(step.Steps, indent)
+					fmt.Fprintf(&buf, "%s%s%s%s: return\n", indent, step.To, syntax.reply, step.From)
+				}
+			case StepLoop, StepOpt:
+				fmt.Fprintf(&buf, "%s%s %s\n", indent, step.Kind, syntax.escape(step.Label))
+				writeSteps(step.Steps, indent+"    ")
+				fmt.Fprintf(&buf, "%send\n", indent)
+			case StepAlt:
+				for i, branch := range step.Branches {
+					keyword := "else"
+					if i == 0 {
+						keyword = "alt"
+					}
+					fmt.Fprintf(&buf, "%s%s\n", indent, strings.TrimSpace(keyword+" "+syntax.escape(branch.Label)))
+					writeSteps(branch.Steps, indent+"    ")
+				}
+				fmt.Fprintf(&buf, "%send\n", indent)
+			}
+		}
+	}
+	writeSteps(d.Steps, "    ")
+	buf.WriteString(syntax.footer)
+	return buf.String(), nil
+}
+
+// SequenceOptions configures a Sequence run.
+type SequenceOptions struct {
+	Depth  int    // Call levels to expand, 1 for the calls of the function only.
+	Format string // Output format: mermaid, plantuml or json.
+	Output string // Output file, stdout when empty.
+}
+
+// Sequence renders the calls made from a function as a sequence diagram.
+func Sequence(project, entry string, opts SequenceOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	diagram, err := BuildSequence(p, entry, opts.Depth)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "mermaid", "plantuml":
+		format := opts.Format
+		if format == "" {
+			format = "mermaid"
+		}
+		out, err := diagram.Render(format)
+		if err != nil {
+			return err
+		}
+		return writeOutput(opts.Output, []byte(out))
+	case "json":
+		return writeJSON(opts.Output, diagram)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
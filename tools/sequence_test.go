@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBuildSequence tests the BuildSequence function and the rendering of its diagrams.
+func TestBuildSequence(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/seq\n\ngo 1.23\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/seq/store"
+)
+
+func main() {
+	s := store.New()
+	defer s.Close()
+	for i := 0; i < 3; i++ {
+		go worker(i)
+	}
+	if err := load(s); err != nil {
+		fmt.Println(err)
+	} else {
+		report()
+	}
+	switch name := "a;b"; name {
+	case "a;b":
+		report()
+	}
+}
+
+func worker(i int) {}
+
+func load(s *store.Store) error {
+	return nil
+}
+
+func report() {
+	fmt.Println(len("done"))
+}
+`,
+		"store/store.go": `package store
+
+type Store struct{}
+
+func New() *Store { return &Store{} }
+
+func (s *Store) Close() {}
+`,
+	}
+	p := loadTestProject(t, files)
+
+	tests := []struct {
+		name   string
+		depth  int
+		format string
+		want   string
+	}{
+		{
+			name:   "mermaid",
+			depth:  2,
+			format: "mermaid",
+			want: `sequenceDiagram
+    participant ___main__8 as . (main)
+    participant store_5 as store
+    participant store_Store_11 as Store
+    participant external_fmt_12 as fmt
+    ___main__8->>store_5: New()
+    loop for i < 3
+        ___main__8-)___main__8: go worker()
+    end
+    ___main__8->>___main__8: load()
+    alt if err != nil
+        ___main__8->>external_fmt_12: Println()
+    else
+        ___main__8->>___main__8: report()
+        ___main__8->>external_fmt_12: Println()
+        ___main__8-->>___main__8: return
+    end
+    opt case "a#59;b"
+        ___main__8->>___main__8: report()
+        ___main__8->>external_fmt_12: Println()
+        ___main__8-->>___main__8: return
+    end
+    ___main__8->>store_Store_11: defer Close()
+`,
+		},
+		{
+			name:   "plantuml",
+			depth:  1,
+			format: "plantuml",
+			want: `@startuml
+    participant ". (main)" as ___main__8
+    participant "store" as store_5
+    participant "Store" as store_Store_11
+    participant "fmt" as external_fmt_12
+    ___main__8->store_5: New()
+    loop for i < 3
+        ___main__8->>___main__8: go worker()
+    end
+    ___main__8->___main__8: load()
+    alt if err != nil
+        ___main__8->external_fmt_12: Println()
+    else
+        ___main__8->___main__8: report()
+    end
+    opt case "a;b"
+        ___main__8->___main__8: report()
+    end
+    ___main__8->store_Store_11: defer Close()
+@enduml
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := BuildSequence(p, "main", tt.depth)
+			if err != nil {
+				t.Fatalf("BuildSequence() error = %v", err)
+			}
+			got, err := diagram.Render(tt.format)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := BuildSequence(p, "fmt.Println", 1); err == nil || !strings.Contains(err.Error(), "not declared") {
+		t.Errorf("BuildSequence() of an external function error = %v, want not declared", err)
+	}
+}
@@ -0,0 +1,410 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// serviceArgs maps the client constructors and request functions of each kind
+// of service to the index of the argument naming the endpoint. An index of -1
+// means the endpoint is set in the Addr, Addrs or Brokers field of an options
+// struct passed to the call.
+var serviceArgs = map[string]map[string]int{
+	"http": {
+		"Get": 0, "Head": 0, "Post": 0, "PostForm": 0,
+		"NewRequest": 1, "NewRequestWithContext": 2,
+	},
+	"sql": {
+		"Open": 1, "MustOpen": 1, "Connect": 1, "MustConnect": 1,
+	},
+	"grpc": {
+		"Dial": 0, "DialContext": 1, "NewClient": 0,
+	},
+	"redis": {
+		"NewClient": -1, "NewClusterClient": -1, "NewFailoverClient": -1, "NewUniversalClient": -1,
+	},
+	"kafka": {
+		"NewWriter": -1, "NewReader": -1,
+		"NewSyncProducer": 0, "NewAsyncProducer": 0, "NewConsumer": 0, "NewConsumerGroup": 0, "NewClient": 0,
+	},
+}
+
+// serviceKind returns the kind of service the package with the given import path talks to.
+func serviceKind(importPath string) string {
+	switch {
+	case importPath == "net/http":
+		return "http"
+	case importPath == "database/sql", importPath == "github.com/jmoiron/sqlx":
+		return "sql"
+	case importPath == "google.golang.org/grpc":
+		return "grpc"
+	case strings.Contains(importPath, "go-redis"):
+		return "redis"
+	case strings.Contains(importPath, "kafka-go"), strings.HasSuffix(importPath, "/sarama"):
+		return "kafka"
+	}
+	return ""
+}
+
+// ServiceCall is a call that connects to an external service.
+type ServiceCall struct {
+	Function string `json:"function"`
+	Kind     string `json:"kind"`     // http, sql, grpc, redis or kafka.
+	Endpoint string `json:"endpoint"` // Host, target or driver; "<dynamic>" when not a literal.
+	Call     string `json:"call"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Service is an external endpoint and the entrypoints that reach it.
+type Service struct {
+	ID          string        `json:"id"`
+	Kind        string        `json:"kind"`
+	Endpoint    string        `json:"endpoint"`
+	Entrypoints []string      `json:"entrypoints"`
+	Calls       []ServiceCall `json:"calls"`
+}
+
+// ServiceMap lists the external services of a project. Binaries maps the
+// directory of every main package to the name of the binary it builds; those
+// directories stand for the binaries in the entrypoints of the services.
+type ServiceMap struct {
+	Services []Service         `json:"services"`
+	Binaries map[string]string `json:"binaries"`
+}
+
+// FindServices locates the calls that talk to external services, takes the
+// endpoint from their literal arguments and links every service to the
+// entrypoints that can reach one of its calls.
+func FindServices(p *Project) (*ServiceMap, error) {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []ServiceCall
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			caller := funcDeclFullName(fd)
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				x, ok := sel.X.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				kind := serviceKind(sf.Imports[x.Name])
+				arg, ok := serviceArgs[kind][sel.Sel.Name]
+				if !ok {
+					return true
+				}
+				calls = append(calls, ServiceCall{
+					Function: caller,
+					Kind:     kind,
+					Endpoint: serviceEndpoint(kind, call, arg),
+					Call:     x.Name + "." + sel.Sel.Name,
+					File:     sf.RelPath,
+					Line:     sf.line(call.Pos()),
+				})
+				return true
+			})
+		}
+	}
+
+	services := make(map[string]*Service)
+	for _, c := range calls {
+		id := c.Kind + ":" + c.Endpoint
+		if services[id] == nil {
+			services[id] = &Service{ID: id, Kind: c.Kind, Endpoint: c.Endpoint}
+		}
+		services[id].Calls = append(services[id].Calls, c)
+	}
+
+	sm := &ServiceMap{Binaries: make(map[string]string)}
+	mains := mainPackages(files)
+	binaries := make(map[string]map[string]bool)
+	for _, dir := range sortedKeys(mains) {
+		if _, ok := mains[dir]["main"]; ok {
+			sm.Binaries[dir] = binaryName(p.Root, dir)
+			binaries[dir] = reachableFromMain(p, mains[dir])
+		}
+	}
+	var entrypoints []*FunctionNode
+	for _, entry := range FindEntrypoints(p) {
+		if entry.Info.PkgName != "main" || entry.Info.Name != "main" {
+			entrypoints = append(entrypoints, entry)
+		}
+	}
+	for _, id := range sortedKeys(services) {
+		s := services[id]
+		for _, dir := range sortedKeys(binaries) {
+			for _, c := range s.Calls {
+				callDir := filepath.ToSlash(filepath.Dir(c.File))
+				_, inMain := mains[callDir]
+				if (callDir == dir && binaries[dir][c.Function]) || (!inMain && binaries[dir][libraryPrefix+c.Function]) {
+					s.Entrypoints = append(s.Entrypoints, dir)
+					break
+				}
+			}
+		}
+		for _, entry := range entrypoints {
+			reachable := reachableFrom(entry)
+			for _, c := range s.Calls {
+				if _, ok := reachable[c.Function]; ok {
+					s.Entrypoints = append(s.Entrypoints, entry.Name)
+					break
+				}
+			}
+		}
+		sm.Services = append(sm.Services, *s)
+	}
+	return sm, nil
+}
+
+// mainPackages maps the directory of every main package to the calls each of
+// its functions makes.
+func mainPackages(files []*sourceFile) map[string]map[string][]string {
+	mains := make(map[string]map[string][]string)
+	for _, sf := range files {
+		if sf.File.Name.Name != "main" {
+			continue
+		}
+		dir := filepath.ToSlash(filepath.Dir(sf.RelPath))
+		if mains[dir] == nil {
+			mains[dir] = make(map[string][]string)
+		}
+		for _, fd := range sf.funcDecls() {
+			name := funcDeclFullName(fd)
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					mains[dir][name] = append(mains[dir][name], sf.callName(call))
+				}
+				return true
+			})
+		}
+	}
+	return mains
+}
+
+// libraryPrefix marks the functions outside the main package in the result of reachableFromMain.
+const libraryPrefix = "lib:"
+
+// reachableFromMain returns the functions reachable from the main function of
+// a main package, given the calls of its functions. The functions of every
+// main package share call graph nodes, so the package is walked from its own
+// declarations and the call graph is only followed out of it; the functions
+// reached outside it are returned with libraryPrefix.
+func reachableFromMain(p *Project, calls map[string][]string) map[string]bool {
+	reachable := map[string]bool{"main": true}
+	queue := []string{"main"}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		var callees []string
+		if lib, ok := strings.CutPrefix(name, libraryPrefix); ok {
+			if node, ok := p.Graph.Nodes[lib]; ok {
+				for _, callee := range sortedKeys(node.Calls) {
+					callees = append(callees, libraryPrefix+callee)
+				}
+			}
+		} else {
+			for _, callee := range calls[name] {
+				if _, local := calls[callee]; !local {
+					callee = libraryPrefix + callee
+				}
+				callees = append(callees, callee)
+			}
+		}
+		for _, callee := range callees {
+			if !reachable[callee] {
+				reachable[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return reachable
+}
+
+// binaryName names a main package after its directory, like go build does.
+func binaryName(root, dir string) string {
+	if dir == "." {
+		dir = root
+	}
+	return filepath.Base(dir)
+}
+
+// serviceEndpoint extracts the endpoint of a service call from its literal arguments.
+func serviceEndpoint(kind string, call *ast.CallExpr, arg int) string {
+	var values []string
+	if arg < 0 {
+		for _, a := range call.Args {
+			values = append(values, addressFields(a)...)
+		}
+	} else if arg < len(call.Args) {
+		values = stringLiterals(call.Args[arg])
+	}
+	if len(values) == 0 {
+		return "<dynamic>"
+	}
+
+	switch kind {
+	case "http":
+		if u, err := url.Parse(values[0]); err == nil && u.Host != "" {
+			return u.Scheme + "://" + u.Host
+		}
+	case "sql":
+		driver := "sql"
+		if lits := stringLiterals(call.Args[0]); len(lits) > 0 {
+			driver = lits[0]
+		}
+		if host := dsnHost(values[0]); host != "" {
+			return driver + "://" + host
+		}
+		return driver
+	}
+	return strings.Join(values, ",")
+}
+
+// dsnHost returns the host of a data source name without its credentials. It
+// understands URL style DSNs and the MySQL style user:pass@tcp(host)/db.
+func dsnHost(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Host != "" {
+		return u.Host + u.Path
+	}
+	if i := strings.Index(dsn, "("); i >= 0 {
+		if j := strings.Index(dsn[i:], ")"); j > 0 {
+			return dsn[i+1 : i+j]
+		}
+	}
+	return ""
+}
+
+// addressFields returns the string literals of the Addr, Addrs and Brokers
+// fields of the composite literals in an expression.
+func addressFields(expr ast.Expr) []string {
+	var values []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		kv, ok := n.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok {
+			switch key.Name {
+			case "Addr", "Addrs", "Brokers":
+				values = append(values, stringLiterals(kv.Value)...)
+			}
+		}
+		return false
+	})
+	return values
+}
+
+// stringLiterals returns the values of the string literals in an expression,
+// leaving out the arguments of nested calls such as os.Getenv("URL").
+func stringLiterals(expr ast.Expr) []string {
+	var values []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if _, ok := n.(*ast.CallExpr); ok {
+			return false
+		}
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		if s, err := strconv.Unquote(lit.Value); err == nil {
+			values = append(values, s)
+		}
+		return true
+	})
+	return values
+}
+
+// String formats the service map as text.
+func (sm *ServiceMap) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Services (%d):\n", len(sm.Services))
+	for _, s := range sm.Services {
+		fmt.Fprintf(&buf, "  %s\n", s.ID)
+		for _, c := range s.Calls {
+			fmt.Fprintf(&buf, "    %s in %s at %s:%d\n", c.Call, c.Function, c.File, c.Line)
+		}
+		if len(s.Entrypoints) > 0 {
+			fmt.Fprintf(&buf, "    reached from: %s\n", strings.Join(s.Entrypoints, ", "))
+		}
+	}
+	return buf.String()
+}
+
+// DOT renders the service level graph: binaries and other entrypoints on the
+// left, the external services they reach on the right.
+func (sm *ServiceMap) DOT() []byte {
+	shapes := map[string]string{"sql": "cylinder", "redis": "cylinder", "kafka": "cds", "grpc": "component", "http": "ellipse"}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph G {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	buf.WriteString("    node [style=filled, fillcolor=lightgray, shape=rectangle];\n")
+
+	sources := make(map[string]bool)
+	for _, s := range sm.Services {
+		for _, entry := range s.Entrypoints {
+			sources[entry] = true
+		}
+	}
+	for _, entry := range sortedKeys(sources) {
+		label, color := entry, "lightgray"
+		if bin, ok := sm.Binaries[entry]; ok {
+			label, color = bin, "#AED6F1" // Light blue
+		}
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\", fillcolor=\"%s\"];\n", sanitizeIdentifier(entry), escapeStringForDOT(label), color))
+	}
+	for _, s := range sm.Services {
+		buf.WriteString(fmt.Sprintf("    \"%s\" [label=\"%s\\n%s\", shape=%s, fillcolor=\"#F9E79F\"];\n",
+			sanitizeIdentifier(s.ID), s.Kind, escapeStringForDOT(s.Endpoint), shapes[s.Kind]))
+		for _, entry := range s.Entrypoints {
+			buf.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\";\n", sanitizeIdentifier(entry), sanitizeIdentifier(s.ID)))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// ServicesOptions configures a Services run.
+type ServicesOptions struct {
+	Format string // Output format: text, json or dot.
+	Output string // Output file, stdout when empty.
+}
+
+// Services reports the external services the project talks to.
+func Services(project string, opts ServicesOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	sm, err := FindServices(p)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "", "text":
+		return writeOutput(opts.Output, []byte(sm.String()))
+	case "json":
+		return writeJSON(opts.Output, sm)
+	case "dot":
+		return writeOutput(opts.Output, sm.DOT())
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindServices tests the FindServices function.
+func TestFindServices(t *testing.T) {
+	p := loadTestProject(t, map[string]string{
+		"cmd/a/main.go": `package main
+
+import "net/http"
+
+func main() {
+	run()
+}
+
+func run() {
+	http.Get("https://api.example.com/users")
+}
+`,
+		"cmd/b/main.go": `package main
+
+import "database/sql"
+
+func main() {
+	run()
+}
+
+func run() {
+	sql.Open("postgres", "postgres://db.example.com:5432/app")
+}
+`,
+	})
+	sm, err := FindServices(p)
+	if err != nil {
+		t.Fatalf("FindServices() error = %v", err)
+	}
+
+	wantBinaries := map[string]string{"cmd/a": "a", "cmd/b": "b"}
+	if !reflect.DeepEqual(sm.Binaries, wantBinaries) {
+		t.Errorf("Binaries = %v, want %v", sm.Binaries, wantBinaries)
+	}
+
+	tests := []struct {
+		id          string
+		entrypoints []string
+	}{
+		{id: "http:https://api.example.com", entrypoints: []string{"cmd/a"}},
+		{id: "sql:postgres://db.example.com:5432/app", entrypoints: []string{"cmd/b"}},
+	}
+	services := make(map[string]Service)
+	for _, s := range sm.Services {
+		services[s.ID] = s
+	}
+	if len(services) != len(tests) {
+		t.Errorf("FindServices() found %d services, want %d", len(services), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			s, ok := services[tt.id]
+			if !ok {
+				t.Fatalf("service %s not found in %+v", tt.id, sm.Services)
+			}
+			if !reflect.DeepEqual(s.Entrypoints, tt.entrypoints) {
+				t.Errorf("Entrypoints = %v, want %v", s.Entrypoints, tt.entrypoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// GraphVersion is the current version of the graph snapshot format. It is
+// bumped whenever a field changes meaning or is removed; added fields keep it.
+const GraphVersion = 1
+
+// GraphSnapshot is the JSON form of a call graph written by analyze --format
+// json and read back by LoadGraph:
+//
+//	{
+//	  "version": 1,
+//	  "root": "/path/to/project",
+//	  "nodes": [{"id": "tools/LoadProject", "name": "LoadProject", "info": {...}, "sites": [...]}],
+//	  "edges": [{"from": "tools/LoadProject", "to": "ext:os.Getwd", "sites": [{"file": "tools/project.go", "line": 331, "column": 14}]}]
+//	}
+//
+// Nodes and edges are sorted by ID so that snapshots of the same code are identical.
+type GraphSnapshot struct {
+	Version int            `json:"version"`
+	Root    string         `json:"root"` // Project root the snapshot was taken from.
+	Nodes   []SnapshotNode `json:"nodes"`
+	Edges   []SnapshotEdge `json:"edges"`
+}
+
+// SnapshotNode is a function of the call graph.
+type SnapshotNode struct {
+	// ID is the package directory and call graph name of a project function,
+	// such as tools/*Baseline.Compare, or ext: and the name of any other.
+	ID     string             `json:"id"`
+	Name   string             `json:"name"`             // Call graph name.
+	Info   *FunctionInfo      `json:"info,omitempty"`   // Declaration and position, nil outside the project.
+	Elided int                `json:"elided,omitempty"` // Functions a placeholder of a filtered graph stands for.
+	Sites  []FunctionCallInfo `json:"sites,omitempty"`  // Calls the function makes, lines relative to its source.
+}
+
+// SnapshotEdge is a call from one node to another.
+type SnapshotEdge struct {
+	From  string     `json:"from"`
+	To    string     `json:"to"`
+	Tags  []string   `json:"tags,omitempty"`  // Such as TagObserved.
+	Label string     `json:"label,omitempty"` // Such as the latency of the call.
+	Sites []Position `json:"sites,omitempty"` // Calls behind the edge, empty for runtime-only calls.
+}
+
+// Position is a location in a project file.
+type Position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// nodeID returns the stable ID of a call graph node.
+func nodeID(node *FunctionNode) string {
+	if node.Info == nil {
+		return "ext:" + node.Name
+	}
+	return path.Join(filepath.ToSlash(filepath.Dir(node.Info.RelativeFilePath)), node.Name)
+}
+
+// callSites returns the source positions of the calls behind every edge of the
+// graph, reading the project sources for the nodes that have no CallSites.
+func callSites(p *Project, graph *CallGraph) (map[Edge][]Position, error) {
+	sites := make(map[Edge][]Position)
+	parse := false
+	for _, node := range graph.Nodes {
+		for callee, positions := range node.CallSites {
+			sites[Edge{From: node.Name, To: callee}] = positions
+		}
+		parse = parse || (node.Info != nil && node.CallSites == nil)
+	}
+	if !parse {
+		return sites, nil
+	}
+
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, sf := range files {
+		for _, fd := range sf.funcDecls() {
+			caller, ok := graph.Nodes[funcDeclFullName(fd)]
+			if !ok || caller.CallSites != nil {
+				continue
+			}
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				callee := sf.callName(call)
+				if _, ok := caller.Calls[callee]; ok {
+					pos := sf.Fset.Position(call.Pos())
+					e := Edge{From: caller.Name, To: callee}
+					sites[e] = append(sites[e], Position{File: filepath.ToSlash(sf.RelPath), Line: pos.Line, Column: pos.Column})
+				}
+				return true
+			})
+		}
+	}
+	return sites, nil
+}
+
+// NewGraphSnapshot builds the snapshot of a call graph of the project.
+func NewGraphSnapshot(p *Project, graph *CallGraph) (*GraphSnapshot, error) {
+	sites, err := callSites(p, graph)
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return nil, err
+	}
+	s := &GraphSnapshot{Version: GraphVersion, Root: root}
+	for _, node := range graph.Nodes {
+		s.Nodes = append(s.Nodes, SnapshotNode{
+			ID:     nodeID(node),
+			Name:   node.Name,
+			Info:   node.Info,
+			Elided: node.Elided,
+			Sites:  node.Sites,
+		})
+		for _, callee := range sortedKeys(node.Calls) {
+			e := Edge{From: node.Name, To: callee}
+			s.Edges = append(s.Edges, SnapshotEdge{
+				From:  nodeID(node),
+				To:    nodeID(node.Calls[callee]),
+				Tags:  node.CallTags[callee],
+				Label: node.CallLabels[callee],
+				Sites: sites[e],
+			})
+		}
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].ID < s.Nodes[j].ID })
+	sort.Slice(s.Edges, func(i, j int) bool {
+		if s.Edges[i].From != s.Edges[j].From {
+			return s.Edges[i].From < s.Edges[j].From
+		}
+		return s.Edges[i].To < s.Edges[j].To
+	})
+	return s, nil
+}
+
+// Project rebuilds the project and its call graph from the snapshot.
+func (s *GraphSnapshot) Project() (*Project, error) {
+	if s.Version != GraphVersion {
+		return nil, fmt.Errorf("unsupported graph version %d", s.Version)
+	}
+	p := &Project{Root: s.Root, Graph: &CallGraph{Nodes: make(map[string]*FunctionNode)}, snapshot: true}
+	for _, n := range s.Nodes {
+		if n.Info != nil {
+			p.Functions = append(p.Functions, *n.Info)
+		}
+	}
+	byID := make(map[string]*FunctionNode, len(s.Nodes))
+	functions := 0
+	for _, n := range s.Nodes {
+		if _, ok := byID[n.ID]; ok {
+			return nil, fmt.Errorf("duplicate node %s", n.ID)
+		}
+		node := &FunctionNode{
+			Name:     n.Name,
+			Calls:    make(map[string]*FunctionNode),
+			CalledBy: make(map[string]*FunctionNode),
+			Elided:   n.Elided,
+			Sites:    n.Sites,
+		}
+		if n.Info != nil {
+			node.Info = &p.Functions[functions]
+			node.CallSites = make(map[string][]Position)
+			functions++
+		}
+		byID[n.ID] = node
+		p.Graph.Nodes[n.Name] = node
+	}
+	for _, e := range s.Edges {
+		from, ok := byID[e.From]
+		if !ok {
+			return nil, fmt.Errorf("edge from unknown node %s", e.From)
+		}
+		to, ok := byID[e.To]
+		if !ok {
+			return nil, fmt.Errorf("edge to unknown node %s", e.To)
+		}
+		from.Calls[to.Name] = to
+		to.CalledBy[from.Name] = from
+		if len(e.Tags) > 0 {
+			if from.CallTags == nil {
+				from.CallTags = make(map[string][]string)
+			}
+			from.CallTags[to.Name] = e.Tags
+		}
+		if e.Label != "" {
+			if from.CallLabels == nil {
+				from.CallLabels = make(map[string]string)
+			}
+			from.CallLabels[to.Name] = e.Label
+		}
+		if from.CallSites != nil && len(e.Sites) > 0 {
+			from.CallSites[to.Name] = e.Sites
+		}
+	}
+	return p, nil
+}
+
+// LoadGraph reads a graph snapshot into a project without parsing its sources.
+// Commands that only need the call graph run from it unchanged; those reading
+// the sources refuse it.
+func LoadGraph(filename string) (*Project, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var s GraphSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse graph %s: %w", filename, err)
+	}
+	p, err := s.Project()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestGraphSnapshot tests that a graph snapshot survives a round trip through JSON.
+func TestGraphSnapshot(t *testing.T) {
+	files := map[string]string{
+		"main.go": `package main
+
+import "example.com/sample/store"
+
+func main() {
+	run(store.New())
+}
+
+func run(s *store.Store) {
+	s.Put("key")
+}
+`,
+		"store/store.go": `package store
+
+import "fmt"
+
+type Store struct{ keys []string }
+
+func New() *Store {
+	return newStore()
+}
+
+func newStore() *Store {
+	return &Store{}
+}
+
+func (s *Store) Put(key string) {
+	s.keys = append(s.keys, key)
+	fmt.Println(key)
+}
+`,
+	}
+	p := loadTestProject(t, files)
+	MergeObservedEdges(p.Graph, []Edge{{From: "run", To: "*Store.Put"}})
+
+	snapshot, err := NewGraphSnapshot(p, p.Graph)
+	if err != nil {
+		t.Fatalf("NewGraphSnapshot() error = %v", err)
+	}
+	filename := filepath.Join(t.TempDir(), "graph.json")
+	if err := writeJSON(filename, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadGraph(filename)
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		edge  SnapshotEdge
+		found bool
+	}{
+		{name: "root package call", edge: SnapshotEdge{From: "main", To: "run", Sites: []Position{{File: "main.go", Line: 6, Column: 2}}}, found: true},
+		{name: "subpackage call", edge: SnapshotEdge{From: "store/New", To: "store/newStore", Sites: []Position{{File: "store/store.go", Line: 8, Column: 9}}}, found: true},
+		{name: "tagged runtime call", edge: SnapshotEdge{From: "run", To: "store/*Store.Put", Tags: []string{TagObserved, TagRuntimeOnly}}, found: true},
+		{name: "external call", edge: SnapshotEdge{From: "store/*Store.Put", To: "ext:fmt.Println", Sites: []Position{{File: "store/store.go", Line: 17, Column: 2}}}, found: true},
+		{name: "missing call", edge: SnapshotEdge{From: "store/New", To: "store/*Store.Put"}, found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *SnapshotEdge
+			for i, e := range snapshot.Edges {
+				if e.From == tt.edge.From && e.To == tt.edge.To {
+					got = &snapshot.Edges[i]
+				}
+			}
+			if (got != nil) != tt.found {
+				t.Fatalf("edge %s -> %s found = %v, want %v", tt.edge.From, tt.edge.To, got != nil, tt.found)
+			}
+			if got != nil && !reflect.DeepEqual(*got, tt.edge) {
+				t.Errorf("edge = %+v, want %+v", *got, tt.edge)
+			}
+		})
+	}
+
+	if node := loaded.Graph.Nodes["*Store.Put"]; node == nil || node.Info == nil || node.Info.LineNumberStart != 15 {
+		t.Errorf("loaded *Store.Put = %+v, want a project function starting on line 15", node)
+	}
+	if _, ok := loaded.Graph.Nodes["*Store.Put"].CalledBy["run"]; !ok {
+		t.Errorf("loaded *Store.Put is not called by run")
+	}
+	for _, site := range loaded.Graph.Nodes["*Store.Put"].Sites {
+		if site.FilePath != "store/store.go" {
+			t.Errorf("loaded call site file = %q, want store/store.go relative to the root", site.FilePath)
+		}
+	}
+	again, err := NewGraphSnapshot(loaded, loaded.Graph)
+	if err != nil {
+		t.Fatalf("NewGraphSnapshot() of the loaded graph error = %v", err)
+	}
+	want, _ := json.Marshal(snapshot)
+	got, _ := json.Marshal(again)
+	if string(got) != string(want) {
+		t.Errorf("snapshot of the loaded graph differs:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := RunRules(loaded, nil); !errors.Is(err, errSnapshotSources) {
+		t.Errorf("RunRules() of the loaded graph error = %v, want %v", err, errSnapshotSources)
+	}
+
+	snapshot.Version = GraphVersion + 1
+	if _, err := snapshot.Project(); err == nil {
+		t.Errorf("Project() of version %d succeeded, want an error", snapshot.Version)
+	}
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sourceFile is a parsed project file shared by the analyses that need more
+// than the call graph, such as statement context or literal values.
+type sourceFile struct {
+	RelPath string
+	Fset    *token.FileSet
+	File    *ast.File
+	Src     []byte
+	Imports map[string]string // import name -> import path
+
+	importSet map[string]struct{} // import names in the form getFunctionName expects
+}
+
+// errSnapshotSources is returned when an analysis needs the sources of a
+// project loaded from a graph snapshot.
+var errSnapshotSources = errors.New("the project was loaded from a graph snapshot and its sources cannot be read, run from the source directory instead")
+
+// parseProjectFiles parses every file that declares one of the project's functions.
+func parseProjectFiles(p *Project) ([]*sourceFile, error) {
+	if p.snapshot {
+		return nil, errSnapshotSources
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, fi := range p.Functions {
+		if !seen[fi.RelativeFilePath] {
+			seen[fi.RelativeFilePath] = true
+			paths = append(paths, fi.RelativeFilePath)
+		}
+	}
+	sort.Strings(paths)
+
+	var files []*sourceFile
+	for _, rel := range paths {
+		sf, err := parseSourceFile(p.Root, rel)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, sf)
+	}
+	return files, nil
+}
+
+// parseSourceFile parses a single file relative to the project root.
+func parseSourceFile(projectRoot, relPath string) (*sourceFile, error) {
+	src, err := os.ReadFile(filepath.Join(projectRoot, relPath))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, relPath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	sf := &sourceFile{RelPath: relPath, Fset: fset, File: file, Src: src, Imports: parseImports(file)}
+	sf.importSet = make(map[string]struct{}, len(sf.Imports))
+	for name := range sf.Imports {
+		sf.importSet[name] = struct{}{}
+	}
+	return sf, nil
+}
+
+// parseImports maps the import names of a file to their import paths.
+func parseImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, imp := range file.Imports {
+		importPath := strings.Trim(imp.Path.Value, `"`)
+		importName := filepath.Base(importPath)
+		if imp.Name != nil {
+			importName = imp.Name.Name
+		}
+		imports[importName] = importPath
+	}
+	return imports
+}
+
+// funcDecls returns the function declarations of the file that have a body.
+func (sf *sourceFile) funcDecls() []*ast.FuncDecl {
+	var decls []*ast.FuncDecl
+	for _, decl := range sf.File.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Body != nil {
+			decls = append(decls, fd)
+		}
+	}
+	return decls
+}
+
+// funcDeclFullName returns the call graph name of a function declaration.
+func funcDeclFullName(fd *ast.FuncDecl) string {
+	fi := FunctionInfo{Name: fd.Name.Name}
+	if fd.Recv != nil && len(fd.Recv.List) > 0 {
+		fi.StructName = exprToString(fd.Recv.List[0].Type)
+	}
+	return getFunctionFullName(fi)
+}
+
+// callName resolves the call graph name of a call expression in this file.
+func (sf *sourceFile) callName(call *ast.CallExpr) string {
+	name, pkg, recv := getFunctionName(call.Fun, sf.importSet)
+	return getCallFullName(FunctionCallInfo{Function: name, Package: pkg, Receiver: recv})
+}
+
+// line returns the line number of a position in this file.
+func (sf *sourceFile) line(pos token.Pos) int {
+	return sf.Fset.Position(pos).Line
+}
+
+// calleeInfos returns the project functions a call may invoke: the function
+// with the call's graph name or, for calls through a variable whose type is
+// unknown, every project method sharing the selector name.
+func (p *Project) calleeInfos(sf *sourceFile, call *ast.CallExpr) []*FunctionInfo {
+	if node, ok := p.Graph.Nodes[sf.callName(call)]; ok && node.Info != nil {
+		return []*FunctionInfo{node.Info}
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	if x, ok := sel.X.(*ast.Ident); ok {
+		if _, isPkg := sf.Imports[x.Name]; isPkg {
+			return nil
+		}
+	}
+	if p.methods == nil {
+		p.methods = make(map[string][]*FunctionInfo)
+		for i := range p.Functions {
+			if fi := &p.Functions[i]; fi.StructName != "" {
+				p.methods[fi.Name] = append(p.methods[fi.Name], fi)
+			}
+		}
+	}
+	return p.methods[sel.Sel.Name]
+}
+
+// qualifiedCalleeInfos returns the project functions a call may invoke like
+// calleeInfos, also resolving the calls to functions of other project packages,
+// which go through the package name, such as those of external test packages.
+func (p *Project) qualifiedCalleeInfos(sf *sourceFile, call *ast.CallExpr) []*FunctionInfo {
+	if infos := p.calleeInfos(sf, call); len(infos) > 0 {
+		return infos
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	importPath, ok := sf.Imports[x.Name]
+	if !ok {
+		return nil
+	}
+	node, ok := p.Graph.Nodes[sel.Sel.Name]
+	if !ok || node.Info == nil || node.Info.PkgName != filepath.Base(importPath) {
+		return nil
+	}
+	return []*FunctionInfo{node.Info}
+}
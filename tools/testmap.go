@@ -0,0 +1,281 @@
+package tools
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TestReach is a function a test reaches, or a test reaching a function, and
+// the number of calls between them.
+type TestReach struct {
+	Name  string `json:"name"`
+	File  string `json:"file"`
+	Depth int    `json:"depth"`
+}
+
+// TestInfo is a test, benchmark, example or fuzz test and the project
+// functions it exercises.
+type TestInfo struct {
+	Name      string      `json:"name"`
+	File      string      `json:"file"`
+	Line      int         `json:"line"`
+	Functions []TestReach `json:"functions"`
+}
+
+// TestMap maps the tests of a project to the functions they statically reach and back.
+type TestMap struct {
+	Tests []TestInfo `json:"tests"`
+	// Functions maps every reached function to the tests reaching it, closest first.
+	Functions map[string][]TestReach `json:"functions"`
+	Untested  []string               `json:"untested"`
+}
+
+// testHelper is a function declared in a _test.go file.
+type testHelper struct {
+	callees []string // Project functions it calls.
+	helpers []string // Other helpers of the package it calls.
+}
+
+// isTestFunction reports whether a function declared in a _test.go file is run by go test.
+func isTestFunction(fd *ast.FuncDecl) bool {
+	if fd.Recv != nil {
+		return false
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+		if rest, ok := strings.CutPrefix(fd.Name.Name, prefix); ok && (rest == "" || !isLowerCase(rest[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLowerCase(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// BuildTestMap parses the _test.go files of the project and walks the call
+// graph from every test, through the test helpers of its package, up to
+// maxDepth calls deep, or without limit when maxDepth is 0.
+func BuildTestMap(p *Project, maxDepth int) (*TestMap, error) {
+	var testFiles []*sourceFile
+	err := filepath.Walk(p.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "vendor" {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		rel, err := filepath.Rel(p.Root, path)
+		if err != nil {
+			return err
+		}
+		sf, err := parseSourceFile(p.Root, rel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing file %s: %v\n", path, err)
+			return nil
+		}
+		testFiles = append(testFiles, sf)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the helpers of each test package, then the tests.
+	helpers := make(map[string]map[string]*testHelper) // Directory -> helper name -> helper.
+	type testDecl struct {
+		info TestInfo
+		dir  string
+		body *testHelper
+	}
+	var tests []testDecl
+	for _, sf := range testFiles {
+		dir := filepath.Dir(sf.RelPath)
+		if helpers[dir] == nil {
+			helpers[dir] = make(map[string]*testHelper)
+		}
+		for _, fd := range sf.funcDecls() {
+			body := &testHelper{}
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if ident, ok := call.Fun.(*ast.Ident); ok {
+						body.helpers = append(body.helpers, ident.Name)
+					}
+					for _, fi := range p.qualifiedCalleeInfos(sf, call) {
+						body.callees = append(body.callees, getFunctionFullName(*fi))
+					}
+				}
+				return true
+			})
+			if isTestFunction(fd) {
+				tests = append(tests, testDecl{
+					info: TestInfo{Name: fd.Name.Name, File: sf.RelPath, Line: sf.line(fd.Pos())},
+					dir:  dir,
+					body: body,
+				})
+			} else {
+				helpers[dir][funcDeclFullName(fd)] = body
+			}
+		}
+	}
+
+	tm := &TestMap{Functions: make(map[string][]TestReach)}
+	for _, t := range tests {
+		// Functions are expanded in order of depth, so that one reached both
+		// through nested helpers and through a shorter call chain keeps the
+		// shorter depth.
+		depths := make(map[string]int)
+		levels := make(map[int][]string)
+		deepest := 0
+		reach := func(name string, depth int) {
+			if d, seen := depths[name]; (seen && d <= depth) || (maxDepth > 0 && depth > maxDepth) {
+				return
+			}
+			depths[name] = depth
+			levels[depth] = append(levels[depth], name)
+			deepest = max(deepest, depth)
+		}
+
+		// Helpers are walked level by level, adding their calls at the depth of the helper call.
+		type helperCall struct {
+			helper *testHelper
+			depth  int
+		}
+		visited := make(map[string]bool)
+		helperQueue := []helperCall{{t.body, 1}}
+		for len(helperQueue) > 0 {
+			h := helperQueue[0]
+			helperQueue = helperQueue[1:]
+			for _, name := range h.helper.callees {
+				reach(name, h.depth)
+			}
+			for _, name := range h.helper.helpers {
+				if next, ok := helpers[t.dir][name]; ok && !visited[name] {
+					visited[name] = true
+					helperQueue = append(helperQueue, helperCall{next, h.depth + 1})
+				}
+			}
+		}
+
+		for depth := 1; depth <= deepest; depth++ {
+			for i := 0; i < len(levels[depth]); i++ {
+				name := levels[depth][i]
+				if depths[name] != depth {
+					continue
+				}
+				for _, callee := range sortedKeys(p.Graph.Nodes[name].Calls) {
+					if p.Graph.Nodes[callee].Info != nil {
+						reach(callee, depth+1)
+					}
+				}
+			}
+		}
+
+		for _, name := range sortedKeys(depths) {
+			t.info.Functions = append(t.info.Functions, TestReach{Name: name, File: p.Graph.Nodes[name].Info.RelativeFilePath, Depth: depths[name]})
+			tm.Functions[name] = append(tm.Functions[name], TestReach{Name: t.info.Name, File: t.info.File, Depth: depths[name]})
+		}
+		sort.SliceStable(t.info.Functions, func(i, j int) bool { return t.info.Functions[i].Depth < t.info.Functions[j].Depth })
+		tm.Tests = append(tm.Tests, t.info)
+	}
+
+	for name, reaches := range tm.Functions {
+		sort.Slice(reaches, func(i, j int) bool {
+			if reaches[i].Depth != reaches[j].Depth {
+				return reaches[i].Depth < reaches[j].Depth
+			}
+			return reaches[i].Name < reaches[j].Name
+		})
+		tm.Functions[name] = reaches
+	}
+	for _, name := range sortedKeys(p.Graph.Nodes) {
+		if _, ok := tm.Functions[name]; !ok && p.Graph.Nodes[name].Info != nil {
+			tm.Untested = append(tm.Untested, name)
+		}
+	}
+	return tm, nil
+}
+
+// String formats the test map as text, for one function or test when name is
+// set, or for all of them.
+func (tm *TestMap) String(name string) string {
+	var buf strings.Builder
+	writeFunction := func(fn string) {
+		fmt.Fprintf(&buf, "%s\n", fn)
+		for _, r := range tm.Functions[fn] {
+			fmt.Fprintf(&buf, "  %d %s (%s)\n", r.Depth, r.Name, r.File)
+		}
+	}
+	writeTest := func(t TestInfo) {
+		fmt.Fprintf(&buf, "%s (%s:%d)\n", t.Name, t.File, t.Line)
+		for _, r := range t.Functions {
+			fmt.Fprintf(&buf, "  %d %s\n", r.Depth, r.Name)
+		}
+	}
+
+	if name != "" {
+		if _, ok := tm.Functions[name]; ok {
+			writeFunction(name)
+		}
+		for _, t := range tm.Tests {
+			if t.Name == name {
+				writeTest(t)
+			}
+		}
+		return buf.String()
+	}
+
+	buf.WriteString("Tests reaching each function (depth, test):\n")
+	for _, fn := range sortedKeys(tm.Functions) {
+		writeFunction(fn)
+	}
+	buf.WriteString("Functions each test exercises (depth, function):\n")
+	for _, t := range tm.Tests {
+		writeTest(t)
+	}
+	fmt.Fprintf(&buf, "Functions no test reaches (%d):\n", len(tm.Untested))
+	for _, fn := range tm.Untested {
+		fmt.Fprintf(&buf, "  %s\n", fn)
+	}
+	return buf.String()
+}
+
+// TestMapOptions configures a TestMapReport run.
+type TestMapOptions struct {
+	Name   string // Only report this function or test.
+	Depth  int    // Maximum number of calls from a test, unlimited when 0.
+	Format string // Output format: text or json.
+	Output string // Output file, stdout when empty.
+}
+
+// TestMapReport reports which tests reach which functions of the project.
+func TestMapReport(project string, opts TestMapOptions) error {
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	tm, err := BuildTestMap(p, opts.Depth)
+	if err != nil {
+		return err
+	}
+	if opts.Name != "" {
+		if node, err := FindNode(p.Graph, opts.Name); err == nil {
+			opts.Name = node.Name
+		}
+	}
+	switch opts.Format {
+	case "", "text":
+		return writeOutput(opts.Output, []byte(tm.String(opts.Name)))
+	case "json":
+		return writeJSON(opts.Output, tm)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
@@ -0,0 +1,110 @@
+package tools
+
+import "testing"
+
+// TestBuildTestMap tests the BuildTestMap function.
+func TestBuildTestMap(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/sample\n\ngo 1.23\n",
+		"calc/calc.go": `package calc
+
+func Add(a, b int) int {
+	return normalize(a) + normalize(b)
+}
+
+func normalize(v int) int {
+	return clamp(v)
+}
+
+func clamp(v int) int {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+func Unused() {}
+`,
+		"calc/calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fail()
+	}
+}
+
+func TestClamp(t *testing.T) {
+	check(t)
+}
+
+func check(t *testing.T) {
+	if clamp(-1) != 0 {
+		t.Fail()
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	normalize(1)
+	outer(t)
+}
+
+func outer(t *testing.T) {
+	inner(t)
+}
+
+func inner(t *testing.T) {
+	clamp(1)
+}
+`,
+		"calc/external_test.go": `package calc_test
+
+import (
+	"testing"
+
+	"example.com/sample/calc"
+)
+
+func BenchmarkAdd(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		calc.Add(i, i)
+	}
+}
+`,
+	}
+	p := loadTestProject(t, files)
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		function string
+		want     map[string]int // Test -> depth.
+	}{
+		{name: "direct call", function: "Add", want: map[string]int{"TestAdd": 1, "BenchmarkAdd": 1}},
+		// TestNormalize reaches clamp through normalize sooner than through its nested helpers.
+		{name: "transitive call", function: "clamp", want: map[string]int{"TestAdd": 3, "BenchmarkAdd": 3, "TestClamp": 2, "TestNormalize": 2}},
+		{name: "max depth", maxDepth: 2, function: "clamp", want: map[string]int{"TestClamp": 2, "TestNormalize": 2}},
+		{name: "untested", function: "Unused", want: map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm, err := BuildTestMap(p, tt.maxDepth)
+			if err != nil {
+				t.Fatalf("BuildTestMap() error = %v", err)
+			}
+			got := make(map[string]int)
+			for _, r := range tm.Functions[tt.function] {
+				got[r.Name] = r.Depth
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("tests reaching %s = %v, want %v", tt.function, got, tt.want)
+			}
+			for name, depth := range tt.want {
+				if got[name] != depth {
+					t.Errorf("depth of %s from %s = %d, want %d", tt.function, name, got[name], depth)
+				}
+			}
+		})
+	}
+}
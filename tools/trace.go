@@ -0,0 +1,336 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/build"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tags of the calls of a call graph.
+const (
+	TagObserved    = "observed"     // The call was seen at runtime.
+	TagRuntimeOnly = "runtime-only" // The call was seen at runtime but is missing from the static analysis.
+)
+
+// traceEnv names the file the instrumented code appends the observed calls to.
+const traceEnv = "GPA_TRACE_OUT"
+
+// traceHelper is added to every instrumented package. gpaTraceEnter records the
+// caller of the function it is called from once per call site, skipping the
+// runtime and reflect frames a call through reflection goes through.
+const traceHelper = `// Code generated by gpa trace. DO NOT EDIT.
+
+package %s
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type gpaTraceKey struct {
+	pc     uintptr
+	callee string
+}
+
+var gpaTraceSeen sync.Map
+
+func gpaTraceEnter(callee string) {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	if n == 0 {
+		return
+	}
+	if _, seen := gpaTraceSeen.LoadOrStore(gpaTraceKey{pcs[0], callee}, true); seen {
+		return
+	}
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") && !strings.HasPrefix(frame.Function, "reflect.") {
+			if f, err := os.OpenFile(os.Getenv(%q), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+				f.WriteString(frame.Function + "\t" + callee + "\n")
+				f.Close()
+			}
+			return
+		}
+		if !more {
+			return
+		}
+	}
+}
+`
+
+// TraceOptions configures a Trace run.
+type TraceOptions struct {
+	Format string // Output format: text, json or dot.
+	Output string // Output file, stdout when empty.
+}
+
+// Trace copies the module containing the project to a temporary directory,
+// instruments every project function to record its callers, runs command in
+// the copy of the project and merges the observed calls into the call graph.
+// A failing command is reported but the calls it made are still merged.
+func Trace(project string, command []string, opts TraceOptions) error {
+	if len(command) == 0 {
+		return fmt.Errorf("no command to trace")
+	}
+	p, err := LoadProject(project)
+	if err != nil {
+		return err
+	}
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return err
+	}
+	moduleRoot := findModuleRoot(root)
+	rel, err := filepath.Rel(moduleRoot, root)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "gpa-trace-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := copyTree(moduleRoot, tmp); err != nil {
+		return fmt.Errorf("failed to copy the module: %w", err)
+	}
+	traced := filepath.Join(tmp, rel)
+	if err := instrumentProject(p, traced); err != nil {
+		return err
+	}
+
+	traceFile := filepath.Join(tmp, "gpa-trace.out")
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = traced
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), traceEnv+"="+traceFile)
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", strings.Join(command, " "), err)
+	}
+
+	observed, err := readTrace(traceFile)
+	if err != nil {
+		return err
+	}
+	runtimeOnly := MergeObservedEdges(p.Graph, observed)
+
+	switch opts.Format {
+	case "", "text":
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "Observed %d calls, %d missing from the static call graph:\n", len(observed), len(runtimeOnly))
+		for _, e := range runtimeOnly {
+			fmt.Fprintf(&buf, "  %s -> %s\n", e.From, e.To)
+		}
+		return writeOutput(opts.Output, []byte(buf.String()))
+	case "json":
+		return writeJSON(opts.Output, struct {
+			Observed    []Edge `json:"observed"`
+			RuntimeOnly []Edge `json:"runtimeOnly"`
+		}{observed, runtimeOnly})
+	case "dot":
+		return GenerateDOT(p.Graph, opts.Output)
+	default:
+		return fmt.Errorf("unknown format: %s", opts.Format)
+	}
+}
+
+// instrumentProject inserts a gpaTraceEnter call at the start of every project
+// function in the copy of the project at dir, keeping line numbers intact, and
+// adds the helper to every instrumented package.
+func instrumentProject(p *Project, dir string) error {
+	files, err := parseProjectFiles(p)
+	if err != nil {
+		return err
+	}
+
+	// Files the build leaves out, such as //go:build ignore generators, may
+	// declare another package and are left as they are.
+	var built []*sourceFile
+	names := make(map[string]map[string]int) // Directory -> package name -> files.
+	for _, sf := range files {
+		pkgDir := filepath.Dir(sf.RelPath)
+		if ok, err := build.Default.MatchFile(filepath.Join(p.Root, pkgDir), filepath.Base(sf.RelPath)); err != nil || !ok {
+			continue
+		}
+		built = append(built, sf)
+		if names[pkgDir] == nil {
+			names[pkgDir] = make(map[string]int)
+		}
+		names[pkgDir][sf.File.Name.Name]++
+	}
+	packages := make(map[string]string) // Directory -> package name.
+	for pkgDir, counts := range names {
+		packages[pkgDir] = majorityPackage(counts)
+	}
+
+	for _, sf := range built {
+		pkgDir := filepath.Dir(sf.RelPath)
+		if packages[pkgDir] != sf.File.Name.Name {
+			continue
+		}
+
+		type insertion struct {
+			offset int
+			text   string
+		}
+		var inserts []insertion
+		for _, fd := range sf.funcDecls() {
+			inserts = append(inserts, insertion{
+				offset: sf.Fset.Position(fd.Body.Lbrace).Offset + 1,
+				text:   "gpaTraceEnter(" + strconv.Quote(funcDeclFullName(fd)) + ");",
+			})
+		}
+		sort.Slice(inserts, func(i, j int) bool { return inserts[i].offset > inserts[j].offset })
+		src := append([]byte{}, sf.Src...)
+		for _, in := range inserts {
+			src = append(src[:in.offset], append([]byte(in.text), src[in.offset:]...)...)
+		}
+		if err := os.WriteFile(filepath.Join(dir, sf.RelPath), src, 0644); err != nil {
+			return err
+		}
+	}
+	for pkgDir, name := range packages {
+		helper := fmt.Sprintf(traceHelper, name, traceEnv)
+		if err := os.WriteFile(filepath.Join(dir, pkgDir, "gpa_trace.go"), []byte(helper), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// majorityPackage returns the package name most files of a directory declare,
+// preferring other packages to main on a tie.
+func majorityPackage(counts map[string]int) string {
+	var best string
+	for _, name := range sortedKeys(counts) {
+		if best == "" || counts[name] > counts[best] || (counts[name] == counts[best] && best == "main") {
+			best = name
+		}
+	}
+	return best
+}
+
+// readTrace reads the calls recorded by the instrumented code, mapping the
+// caller symbols to call graph names.
+func readTrace(filename string) ([]Edge, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seen := make(map[Edge]bool)
+	var edges []Edge
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		caller, callee, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+		e := Edge{From: symbolNodeName(caller), To: callee}
+		if e.From != e.To && !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges, scanner.Err()
+}
+
+// MergeObservedEdges tags the calls seen at runtime as observed, adding the
+// ones the static analysis missed, and returns the added calls. Calls from
+// functions outside the project, such as tests, are ignored.
+func MergeObservedEdges(graph *CallGraph, edges []Edge) []Edge {
+	var added []Edge
+	for _, e := range edges {
+		from, ok := graph.Nodes[e.From]
+		if !ok || from.Info == nil {
+			continue
+		}
+		to, ok := graph.Nodes[e.To]
+		if !ok {
+			continue
+		}
+		if from.CallTags == nil {
+			from.CallTags = make(map[string][]string)
+		}
+		from.CallTags[e.To] = append(from.CallTags[e.To], TagObserved)
+		if _, ok := from.Calls[e.To]; !ok {
+			from.Calls[e.To] = to
+			to.CalledBy[e.From] = from
+			from.CallTags[e.To] = append(from.CallTags[e.To], TagRuntimeOnly)
+			added = append(added, e)
+		}
+	}
+	return added
+}
+
+// findModuleRoot returns the directory of the go.mod file governing dir, or dir
+// itself when there is none.
+func findModuleRoot(dir string) string {
+	for d := dir; filepath.Dir(d) != d; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+	}
+	return dir
+}
+
+// copyTree copies the directory tree at src to dst, leaving out .git.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestInstrumentProject tests that an instrumented project records the calls
+// readTrace reads back and MergeObservedEdges adds to the call graph.
+func TestInstrumentProject(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test on the instrumented project")
+	}
+	p := loadTestProject(t, map[string]string{
+		"go.mod": "module example.com/sample\n\ngo 1.21\n",
+		"a_gen.go": `//go:build ignore
+
+package main
+
+func main() {}
+`,
+		"lib.go": `package sample
+
+func Run() {
+	helper()
+	call(later)
+}
+
+func helper() {}
+
+func call(fn func()) {
+	fn()
+}
+
+func later() {}
+`,
+		"lib_test.go": `package sample
+
+import "testing"
+
+func TestRun(t *testing.T) {
+	Run()
+}
+`,
+	})
+
+	traced := t.TempDir()
+	if err := copyTree(p.Root, traced); err != nil {
+		t.Fatal(err)
+	}
+	if err := instrumentProject(p, traced); err != nil {
+		t.Fatalf("instrumentProject() error = %v", err)
+	}
+	if src, err := os.ReadFile(filepath.Join(traced, "a_gen.go")); err != nil || string(src) != "//go:build ignore\n\npackage main\n\nfunc main() {}\n" {
+		t.Errorf("instrumentProject() changed the ignored file: %s", src)
+	}
+
+	traceFile := filepath.Join(t.TempDir(), "trace.out")
+	cmd := exec.Command("go", "test", "-count=1", ".")
+	cmd.Dir = traced
+	cmd.Env = append(os.Environ(), traceEnv+"="+traceFile)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test on the instrumented project failed: %v\n%s", err, out)
+	}
+
+	observed, err := readTrace(traceFile)
+	if err != nil {
+		t.Fatalf("readTrace() error = %v", err)
+	}
+	wantObserved := []Edge{
+		{From: "Run", To: "call"},
+		{From: "Run", To: "helper"},
+		{From: "TestRun", To: "Run"},
+		{From: "call", To: "later"},
+	}
+	if !reflect.DeepEqual(observed, wantObserved) {
+		t.Errorf("readTrace() = %v, want %v", observed, wantObserved)
+	}
+
+	added := MergeObservedEdges(p.Graph, observed)
+	if want := []Edge{{From: "call", To: "later"}}; !reflect.DeepEqual(added, want) {
+		t.Errorf("MergeObservedEdges() = %v, want %v", added, want)
+	}
+	tests := []struct {
+		from, to string
+		want     []string
+	}{
+		{from: "Run", to: "helper", want: []string{TagObserved}},
+		{from: "call", to: "later", want: []string{TagObserved, TagRuntimeOnly}},
+	}
+	for _, tt := range tests {
+		if got := p.Graph.Nodes[tt.from].CallTags[tt.to]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CallTags of %s -> %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}